@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"myfacebook/internal/config"
+	"myfacebook/internal/db"
+	"myfacebook/internal/postfeedcache"
+	"myfacebook/internal/rdb"
+	sqlxrepo "myfacebook/internal/repository/sqlx"
+)
+
+// runFeedMigrate converts the feeds left as lists by the previous versions into sorted sets and sets the feed
+// expiration to the feeds left without one. It is run once after upgrading, the converted and expiring feeds
+// are skipped on the next runs.
+func runFeedMigrate() error {
+	envConfig := config.GetConfigFromEnv()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: logLevel(envConfig.LogLevel),
+	}))
+	slog.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	writeDB := db.New(writeDBConfig(envConfig))
+
+	if err := writeDB.Connect(ctx); err != nil {
+		return fmt.Errorf("cannot connect to write db: %w", err)
+	}
+
+	defer func() {
+		if err := writeDB.Disconnect(); err != nil {
+			log.Fatalf("Failed to disconnect from write db: %s", err)
+		}
+	}()
+
+	readDB := db.New(readDBConfig(envConfig))
+
+	if err := readDB.Connect(ctx); err != nil {
+		return fmt.Errorf("cannot connect to read db: %w", err)
+	}
+
+	defer func() {
+		if err := readDB.Disconnect(); err != nil {
+			log.Fatalf("Failed to disconnect from read db: %s", err)
+		}
+	}()
+
+	redisDB := rdb.New(redisConfig(envConfig))
+
+	if err := redisDB.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	defer func() {
+		if err := redisDB.Disconnect(); err != nil {
+			log.Fatalf("Failed to disconnect from redis: %s", err)
+		}
+	}()
+
+	postRepository := sqlxrepo.NewPostRepository(writeDB, readDB)
+
+	postFeedCache := postfeedcache.New(redisDB, envConfig.PostFeedMaxLength,
+		time.Duration(envConfig.PostFeedTTLHours)*time.Hour)
+
+	migratedFeedsCount, err := postFeedCache.MigrateLists(ctx, func(ctx context.Context, postIDs []string) (map[string]time.Time, error) {
+		posts, err := postRepository.GetPostsByIDs(ctx, postIDs, 0, len(postIDs))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get posts: %w", err)
+		}
+
+		createdAt := make(map[string]time.Time, len(posts))
+		for _, post := range posts {
+			createdAt[post.ID] = post.CreatedAt
+		}
+
+		return createdAt, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to migrate post feeds to sorted sets after %d feeds: %w", migratedFeedsCount, err)
+	}
+
+	slog.Info(fmt.Sprintf("Migrated %d post feeds to sorted sets", migratedFeedsCount))
+
+	expiredFeedKeysCount, err := postFeedCache.ExpireFeeds(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set post feeds expiration after %d keys: %w", expiredFeedKeysCount, err)
+	}
+
+	slog.Info(fmt.Sprintf("Set expiration to %d post feed keys", expiredFeedKeysCount))
+
+	return nil
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"myfacebook/internal/config"
+	"myfacebook/internal/db"
+	"myfacebook/internal/postcache"
+	"myfacebook/internal/postfeedbuilder"
+	"myfacebook/internal/postfeedcache"
+	"myfacebook/internal/postvisibility"
+	"myfacebook/internal/rdb"
+	sqlxrepo "myfacebook/internal/repository/sqlx"
+)
+
+type feedRebuildOptions struct {
+	userID      string
+	since       time.Time
+	dryRun      bool
+	batchSize   int
+	concurrency int
+	rate        float64
+	reset       bool
+	retryFailed bool
+}
+
+// runFeedRebuild rebuilds the users feeds in Redis from the db,
+// e.g. after Redis is flushed or the feed logic is changed.
+func runFeedRebuild(args []string) error {
+	options, err := parseFeedRebuildOptions(args)
+	if err != nil {
+		return err
+	}
+
+	envConfig := config.GetConfigFromEnv()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: logLevel(envConfig.LogLevel),
+	}))
+	slog.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	writeDB := db.New(writeDBConfig(envConfig))
+
+	if err := writeDB.Connect(ctx); err != nil {
+		return fmt.Errorf("cannot connect to write db: %w", err)
+	}
+
+	defer func() {
+		if err := writeDB.Disconnect(); err != nil {
+			log.Fatalf("Failed to disconnect from write db: %s", err)
+		}
+	}()
+
+	readDB := db.New(readDBConfig(envConfig))
+
+	if err := readDB.Connect(ctx); err != nil {
+		return fmt.Errorf("cannot connect to read db: %w", err)
+	}
+
+	defer func() {
+		if err := readDB.Disconnect(); err != nil {
+			log.Fatalf("Failed to disconnect from read db: %s", err)
+		}
+	}()
+
+	redisDB := rdb.New(redisConfig(envConfig))
+
+	if err := redisDB.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	defer func() {
+		if err := redisDB.Disconnect(); err != nil {
+			log.Fatalf("Failed to disconnect from redis: %s", err)
+		}
+	}()
+
+	userRepository := sqlxrepo.NewUserRepository(writeDB, readDB)
+	postRepository := sqlxrepo.NewPostRepository(writeDB, readDB)
+
+	postFeedCache := postfeedcache.New(redisDB, envConfig.PostFeedMaxLength,
+		time.Duration(envConfig.PostFeedTTLHours)*time.Hour)
+	postCache := postcache.New(redisDB)
+	postVisibilityChecker := postvisibility.New(postRepository, userRepository)
+	postFeedBuilder := postfeedbuilder.New(postRepository, postFeedCache, postCache, postVisibilityChecker)
+
+	rebuildFeed := func(ctx context.Context, userID string) error {
+		if !options.dryRun {
+			return postFeedBuilder.Rebuild(ctx, userID, options.since) //nolint:wrapcheck
+		}
+
+		posts, err := postFeedBuilder.FeedPosts(ctx, userID, options.since)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		slog.Info(fmt.Sprintf("Dry run, feed of user %s would get %d posts", userID, len(posts)))
+
+		return nil
+	}
+
+	if options.userID != "" {
+		if err := rebuildFeed(ctx, options.userID); err != nil {
+			return fmt.Errorf("failed to rebuild feed of user %s: %w", options.userID, err)
+		}
+
+		slog.Info(fmt.Sprintf("Rebuilt feed of user %s", options.userID))
+
+		return nil
+	}
+
+	var throttle <-chan time.Time
+
+	if options.rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / options.rate))
+		defer ticker.Stop()
+
+		throttle = ticker.C
+	}
+
+	var rebuiltCount, failedCount atomic.Int64
+
+	// rebuildFeeds rebuilds the feeds of the users, the failed users are kept in Redis to be retried.
+	// When retrying, the users rebuilt this time are dropped from there.
+	rebuildFeeds := func(userIDs []string, retrying bool) error {
+		var (
+			wg        sync.WaitGroup
+			saveErrMu sync.Mutex
+			saveErr   error
+		)
+
+		semaphore := make(chan struct{}, options.concurrency)
+
+		for _, userID := range userIDs {
+			if throttle != nil {
+				select {
+				case <-throttle:
+				case <-ctx.Done():
+				}
+			}
+
+			if ctx.Err() != nil {
+				break
+			}
+
+			semaphore <- struct{}{}
+
+			wg.Add(1)
+
+			go func(userID string) {
+				defer func() {
+					<-semaphore
+					wg.Done()
+				}()
+
+				var err error
+
+				if rebuildErr := rebuildFeed(ctx, userID); rebuildErr != nil {
+					failedCount.Add(1)
+					slog.Error(fmt.Sprintf("Failed to rebuild feed of user %s: %s", userID, rebuildErr))
+
+					if !options.dryRun && !retrying {
+						err = postFeedCache.AddRebuildFailed(ctx, userID)
+					}
+				} else {
+					rebuiltCount.Add(1)
+
+					if !options.dryRun && retrying {
+						err = postFeedCache.RemoveRebuildFailed(ctx, userID)
+					}
+				}
+
+				if err != nil {
+					saveErrMu.Lock()
+					saveErr = errors.Join(saveErr, err)
+					saveErrMu.Unlock()
+				}
+			}(userID)
+		}
+
+		wg.Wait()
+
+		return saveErr
+	}
+
+	// retryFailed rebuilds the feeds failed by this or the previous runs once more, the ones failing again stay in Redis.
+	retryFailed := func() error {
+		userIDs, err := postFeedCache.GetRebuildFailed(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get failed users ids: %w", err)
+		}
+
+		if len(userIDs) == 0 {
+			return nil
+		}
+
+		slog.Info(fmt.Sprintf("Retrying feed rebuild of %d failed users", len(userIDs)))
+
+		failedBefore := failedCount.Load()
+
+		if err := rebuildFeeds(userIDs, true); err != nil {
+			return fmt.Errorf("failed to save feed rebuild failures: %w", err)
+		}
+
+		if failed := failedCount.Load() - failedBefore; failed > 0 && ctx.Err() == nil {
+			slog.Warn(fmt.Sprintf("Feeds of %d users failed to rebuild again, run feed rebuild --retry-failed to retry them",
+				failed))
+		}
+
+		return nil
+	}
+
+	if options.retryFailed {
+		if err := retryFailed(); err != nil {
+			return err
+		}
+
+		slog.Info(fmt.Sprintf("Feed rebuild retry finished, rebuilt %d feeds, failed %d",
+			rebuiltCount.Load(), failedCount.Load()))
+
+		return nil
+	}
+
+	if options.reset && !options.dryRun {
+		if err := postFeedCache.DeleteRebuildProgress(ctx); err != nil {
+			return fmt.Errorf("failed to reset feed rebuild progress: %w", err)
+		}
+
+		// the users failed before are walked again from the start
+		if err := postFeedCache.DeleteRebuildFailed(ctx); err != nil {
+			return fmt.Errorf("failed to reset feed rebuild failures: %w", err)
+		}
+	}
+
+	lastUserID, err := postFeedCache.GetRebuildProgress(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get feed rebuild progress: %w", err)
+	}
+
+	if lastUserID != "" {
+		slog.Info(fmt.Sprintf("Resuming feed rebuild after user %s", lastUserID))
+	}
+
+	for {
+		userIDs, err := userRepository.GetUsersIDsAfter(ctx, lastUserID, options.batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get users ids: %w", err)
+		}
+
+		if len(userIDs) == 0 {
+			break
+		}
+
+		// the progress is not saved past the failed users that were not remembered
+		if err := rebuildFeeds(userIDs, false); err != nil {
+			return fmt.Errorf("failed to save feed rebuild failures after user %s: %w", lastUserID, err)
+		}
+
+		// an interrupted batch is not saved, so it is walked again on resume
+		if ctx.Err() != nil {
+			slog.Info(fmt.Sprintf("Feed rebuild interrupted after user %s, rebuilt %d feeds, failed %d",
+				lastUserID, rebuiltCount.Load(), failedCount.Load()))
+
+			return nil
+		}
+
+		lastUserID = userIDs[len(userIDs)-1]
+
+		if !options.dryRun {
+			if err := postFeedCache.SetRebuildProgress(ctx, lastUserID); err != nil {
+				return fmt.Errorf("failed to save feed rebuild progress: %w", err)
+			}
+		}
+
+		slog.Info(fmt.Sprintf("Feed rebuild reached user %s, rebuilt %d feeds, failed %d",
+			lastUserID, rebuiltCount.Load(), failedCount.Load()))
+	}
+
+	if !options.dryRun {
+		if err := postFeedCache.DeleteRebuildProgress(ctx); err != nil {
+			return fmt.Errorf("failed to delete feed rebuild progress: %w", err)
+		}
+
+		if err := retryFailed(); err != nil {
+			return err
+		}
+	}
+
+	slog.Info(fmt.Sprintf("Feed rebuild finished, rebuilt %d feeds, failed %d", rebuiltCount.Load(), failedCount.Load()))
+
+	return nil
+}
+
+func parseFeedRebuildOptions(args []string) (*feedRebuildOptions, error) {
+	var options feedRebuildOptions
+
+	var since string
+
+	flagSet := flag.NewFlagSet("feed rebuild", flag.ContinueOnError)
+
+	flagSet.StringVar(&options.userID, "user", "", "rebuild the feed of the given user only")
+	flagSet.StringVar(&since, "since", "", "take only posts created since the given time, RFC3339 or 2006-01-02")
+	flagSet.BoolVar(&options.dryRun, "dry-run", false, "count the feed posts without writing to redis")
+	flagSet.IntVar(&options.batchSize, "batch", 100, "number of users read from the db at once")
+	flagSet.IntVar(&options.concurrency, "concurrency", 4, "number of feeds rebuilt at the same time")
+	flagSet.Float64Var(&options.rate, "rate", 0, "max number of feeds rebuilt per second, 0 is unlimited")
+	flagSet.BoolVar(&options.reset, "reset", false, "start from the first user instead of the saved progress")
+	flagSet.BoolVar(&options.retryFailed, "retry-failed", false, "rebuild only the feeds failed by the previous runs")
+
+	if err := flagSet.Parse(args); err != nil {
+		return nil, fmt.Errorf("failed to parse feed rebuild flags: %w", err)
+	}
+
+	if since != "" {
+		var err error
+
+		options.since, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			options.since, err = time.Parse(time.DateOnly, since)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid since %q, expected RFC3339 or 2006-01-02", since)
+		}
+	}
+
+	if options.batchSize < 1 {
+		return nil, errors.New("batch must be positive")
+	}
+
+	if options.concurrency < 1 {
+		return nil, errors.New("concurrency must be positive")
+	}
+
+	if options.rate < 0 {
+		return nil, errors.New("rate must not be negative")
+	}
+
+	return &options, nil
+}
@@ -8,6 +8,7 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -32,17 +33,43 @@ import (
 	"myfacebook/internal/httpserver"
 	internalapihandler "myfacebook/internal/internalapi/handler"
 	internalapimiddleware "myfacebook/internal/internalapi/middleware"
+	"myfacebook/internal/moderation"
 	"myfacebook/internal/myfacebookdialogapiclient"
+	"myfacebook/internal/postcache"
 	"myfacebook/internal/postfanoutservice"
+	"myfacebook/internal/postfeedbuilder"
 	"myfacebook/internal/postfeedcache"
+	"myfacebook/internal/postpollcache"
+	"myfacebook/internal/postpurger"
+	"myfacebook/internal/postranker"
+	"myfacebook/internal/postreactioncache"
+	"myfacebook/internal/postreactionreconciler"
+	"myfacebook/internal/postscheduler"
+	"myfacebook/internal/postviewcache"
+	"myfacebook/internal/postviewrecorder"
+	"myfacebook/internal/postvisibility"
 	"myfacebook/internal/rdb"
+	"myfacebook/internal/repository"
 	"myfacebook/internal/repository/rest"
 	sqlxrepo "myfacebook/internal/repository/sqlx"
 	"myfacebook/internal/rmq"
+	"myfacebook/internal/userstats"
+	"myfacebook/internal/userstatscache"
 )
 
 func main() {
-	if err := run(); err != nil {
+	var err error
+
+	switch {
+	case len(os.Args) > 2 && os.Args[1] == "feed" && os.Args[2] == "rebuild":
+		err = runFeedRebuild(os.Args[3:])
+	case len(os.Args) > 2 && os.Args[1] == "feed" && os.Args[2] == "migrate":
+		err = runFeedMigrate()
+	default:
+		err = run()
+	}
+
+	if err != nil {
 		log.Fatalf("Application error: %s", err)
 	}
 }
@@ -68,17 +95,7 @@ func run() error {
 		}
 	}()
 
-	writeDB := db.New(db.Config{
-		DriverName:         envConfig.WriteDBDriverName,
-		Host:               envConfig.WriteDBHost,
-		Port:               envConfig.WriteDBPort,
-		Username:           envConfig.WriteDBUsername,
-		Password:           envConfig.WriteDBPassword,
-		DBName:             envConfig.WriteDBName,
-		SSLMode:            envConfig.WriteDBSSLMode,
-		MaxOpenConnections: envConfig.WriteDBMaxOpenConnections,
-		MigrationPath:      "./storage/migrations",
-	})
+	writeDB := db.New(writeDBConfig(envConfig))
 
 	if err := writeDB.Connect(ctx); err != nil {
 		return fmt.Errorf("cannot connect to write db: %w", err)
@@ -96,16 +113,7 @@ func run() error {
 		return fmt.Errorf("writeDB migration failed: %w", err)
 	}
 
-	readDB := db.New(db.Config{
-		DriverName:         envConfig.ReadDBDriverName,
-		Host:               envConfig.ReadDBHost,
-		Port:               envConfig.ReadDBPort,
-		Username:           envConfig.ReadDBUsername,
-		Password:           envConfig.ReadDBPassword,
-		DBName:             envConfig.ReadDBName,
-		SSLMode:            envConfig.ReadDBSSLMode,
-		MaxOpenConnections: envConfig.ReadDBMaxOpenConnections,
-	})
+	readDB := db.New(readDBConfig(envConfig))
 
 	if err := readDB.Connect(ctx); err != nil {
 		return fmt.Errorf("cannot connect to read db: %w", err)
@@ -129,11 +137,23 @@ func run() error {
 			Name: "/post/feed/posted",
 			Kind: "direct",
 		},
+		{
+			Name: "/user/notifications",
+			Kind: "direct",
+		},
+		{
+			Name: "/post/comments/created",
+			Kind: "direct",
+		},
 	}, []rmq.Queue{
 		{
 			Name:    "/post/feed",
 			Durable: true,
 		},
+		{
+			Name:    "/post/feed/failed",
+			Durable: true,
+		},
 	})
 
 	if err := rabbitMQ.Connect(ctx); err != nil {
@@ -167,14 +187,17 @@ func run() error {
 
 	userRepository := sqlxrepo.NewUserRepository(writeDB, readDB)
 	postRepository := sqlxrepo.NewPostRepository(writeDB, readDB)
+	postReactionRepository := sqlxrepo.NewPostReactionRepository(writeDB, readDB)
+	commentRepository := sqlxrepo.NewCommentRepository(writeDB, readDB)
+	postDraftRepository := sqlxrepo.NewPostDraftRepository(writeDB, readDB)
+	postStatsRepository := sqlxrepo.NewPostStatsRepository(writeDB, readDB)
+	bookmarkRepository := sqlxrepo.NewBookmarkRepository(writeDB, readDB)
+	pollRepository := sqlxrepo.NewPollRepository(writeDB, readDB)
+	reportRepository := sqlxrepo.NewReportRepository(writeDB, readDB)
+	userStatsRepository := sqlxrepo.NewUserStatsRepository(writeDB, readDB)
 	dialogRepository := rest.NewDialogRepository(myfacebookDialogAPIClient)
 
-	redisDB := rdb.New(&rdb.Config{
-		Host:     envConfig.RedisHost,
-		Port:     envConfig.RedisPort,
-		Password: envConfig.RedisPassword,
-		DBNum:    envConfig.RedisDBNum,
-	})
+	redisDB := rdb.New(redisConfig(envConfig))
 
 	if err := redisDB.Connect(ctx); err != nil {
 		return fmt.Errorf("failed to connect to redis: %w", err)
@@ -186,9 +209,18 @@ func run() error {
 		}
 	}()
 
-	postFeedCache := postfeedcache.New(redisDB)
+	postFeedCache := postfeedcache.New(redisDB, envConfig.PostFeedMaxLength,
+		time.Duration(envConfig.PostFeedTTLHours)*time.Hour)
+	postCache := postcache.New(redisDB)
 
-	postFanoutService := postfanoutservice.New(rabbitMQ, userRepository, postFeedCache, envConfig)
+	postVisibilityChecker := postvisibility.New(postRepository, userRepository)
+
+	postFeedBuilder := postfeedbuilder.New(postRepository, postFeedCache, postCache, postVisibilityChecker)
+
+	userStatsCounter := userstats.New(userStatsRepository, userstatscache.New(redisDB), envConfig)
+
+	postFanoutService := postfanoutservice.New(rabbitMQ, userRepository, postFeedCache, postCache, postVisibilityChecker,
+		userStatsCounter, envConfig)
 
 	err = postFanoutService.Start(ctx)
 	if err != nil {
@@ -197,6 +229,63 @@ func run() error {
 
 	defer postFanoutService.Stop()
 
+	postReactionCache := postreactioncache.New(redisDB)
+
+	postRankerSelector, err := newPostRankerSelector(envConfig, postRepository, userRepository, postReactionRepository,
+		commentRepository, dialogRepository, postReactionCache)
+	if err != nil {
+		return err
+	}
+
+	postReactionReconciler := postreactionreconciler.New(postReactionRepository, postReactionCache,
+		time.Duration(envConfig.PostReactionsReconcileIntervalSeconds)*time.Second)
+
+	postReactionReconciler.Start(ctx)
+	defer postReactionReconciler.Stop()
+
+	postViewCache := postviewcache.New(redisDB)
+
+	postPollCache := postpollcache.New(redisDB)
+
+	postViewRecorder := postviewrecorder.New(postViewCache, postStatsRepository,
+		time.Duration(envConfig.PostViewsFlushIntervalSeconds)*time.Second)
+
+	postViewRecorder.Start(ctx)
+	defer postViewRecorder.Stop()
+
+	moderationPipeline := moderation.NewPipeline(
+		moderation.NewBannedWordsCheck(envConfig.ModerationBannedWords),
+		moderation.NewRepeatedCharsCheck(envConfig.ModerationMaxRepeatedChars),
+		moderation.NewLinksCheck(envConfig.ModerationMaxLinks),
+		moderation.NewRateCheck(redisDB, envConfig.ModerationRateLimit,
+			time.Duration(envConfig.ModerationRateWindowSeconds)*time.Second),
+	)
+
+	postPurger := postpurger.New(postRepository, postDraftRepository, postViewCache,
+		time.Duration(envConfig.PostTrashRetentionHours)*time.Hour,
+		time.Duration(envConfig.PostTrashPurgeIntervalMinutes)*time.Minute)
+
+	postPurger.Start(ctx)
+	defer postPurger.Stop()
+
+	postScheduler := postscheduler.New(postRepository, rabbitMQ,
+		time.Duration(envConfig.PostSchedulerIntervalSeconds)*time.Second)
+
+	postScheduler.Start(ctx)
+	defer postScheduler.Stop()
+
+	postHydrator := &handler.PostHydrator{
+		PostRepository:         postRepository,
+		UserRepository:         userRepository,
+		PostVisibilityChecker:  postVisibilityChecker,
+		PostReactionRepository: postReactionRepository,
+		CommentRepository:      commentRepository,
+		PollRepository:         pollRepository,
+		PostReactionCache:      postReactionCache,
+		PostPollCache:          postPollCache,
+		PostCache:              postCache,
+	}
+
 	router := httprouter.New(httprouter.NewRegexRouteFactory())
 
 	requestResponseMiddleware := httproutermiddleware.NewRequestResponseLog()
@@ -233,47 +322,279 @@ func run() error {
 			router.Use(apiv1AuthMiddleware)
 
 			router.Put(`/friend/add/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}`, &handler.AddFriend{
-				UserRepository: userRepository,
+				UserRepository:   userRepository,
+				UserStatsCounter: userStatsCounter,
 			}, "/friend/add/{id}")
 
 			router.Put(`/friend/delete/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}`, &handler.DeleteFriend{
-				UserRepository: userRepository,
-				PostRepository: postRepository,
-				PostFeedCache:  postFeedCache,
+				UserRepository:   userRepository,
+				PostRepository:   postRepository,
+				PostFeedCache:    postFeedCache,
+				UserStatsCounter: userStatsCounter,
 			}, "/friend/delete/{id}")
 
+			router.Put(`/friend/mute/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}`, &handler.MuteFriend{
+				UserRepository: userRepository,
+			}, "/friend/mute/{id}")
+
+			router.Put(`/friend/unmute/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}`, &handler.UnmuteFriend{
+				UserRepository:  userRepository,
+				PostFeedBuilder: postFeedBuilder,
+			}, "/friend/unmute/{id}")
+
 			router.Get("/post/get/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", &handler.GetPost{
-				PostRepository: postRepository,
+				PostRepository:        postRepository,
+				PostVisibilityChecker: postVisibilityChecker,
+				PostHydrator:          postHydrator,
+				PostViewRecorder:      postViewRecorder,
 			}, "/post/get/{id}")
 
-			router.Post("/post/create", &handler.CreatePost{
-				PostRepository: postRepository,
-				RMQ:            rabbitMQ,
-			}, "/post/create")
+			createPostHandler := &handler.CreatePost{
+				PostRepository:     postRepository,
+				UserRepository:     userRepository,
+				PollRepository:     pollRepository,
+				ModerationPipeline: moderationPipeline,
+				PostPollCache:      postPollCache,
+				RMQ:                rabbitMQ,
+			}
+
+			router.Post("/post/create", createPostHandler, "/post/create")
 
 			router.Put("/post/update", &handler.UpdatePost{
-				PostRepository: postRepository,
+				PostRepository:     postRepository,
+				UserRepository:     userRepository,
+				ModerationPipeline: moderationPipeline,
+				PostCache:          postCache,
+				RMQ:                rabbitMQ,
 			}, "/post/update")
 
 			router.Put("/post/delete/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", &handler.DeletePost{
 				PostRepository: postRepository,
+				PostCache:      postCache,
 				RMQ:            rabbitMQ,
 			}, "/post/delete/{id}")
 
-			router.Get("/post/feed", &handler.PostFeed{
+			router.Get("/post/trash", &handler.PostTrash{
 				PostRepository: postRepository,
-				UserRepository: userRepository,
-				PostFeedCache:  postFeedCache,
-				EnvConfig:      envConfig,
+				PostHydrator:   postHydrator,
+			}, "/post/trash")
+
+			router.Put("/post/restore/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", &handler.RestorePost{
+				PostRepository: postRepository,
+				PostCache:      postCache,
+				RMQ:            rabbitMQ,
+			}, "/post/restore/{id}")
+
+			router.Post("/post/draft", &handler.CreatePostDraft{
+				PostDraftRepository: postDraftRepository,
+				EnvConfig:           envConfig,
+			}, "/post/draft")
+
+			router.Put("/post/draft/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", &handler.UpdatePostDraft{
+				PostDraftRepository: postDraftRepository,
+				EnvConfig:           envConfig,
+			}, "/post/draft/{id}")
+
+			router.Post("/post/draft/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/publish", &handler.PublishPostDraft{
+				PostDraftRepository: postDraftRepository,
+				CreatePost:          createPostHandler,
+			}, "/post/draft/{id}/publish")
+
+			router.Get("/post/drafts", &handler.PostDrafts{
+				PostDraftRepository: postDraftRepository,
+			}, "/post/drafts")
+
+			router.Get("/post/scheduled", &handler.ScheduledPosts{
+				PostRepository: postRepository,
+			}, "/post/scheduled")
+
+			router.Put("/post/scheduled/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", &handler.ReschedulePost{
+				PostRepository: postRepository,
+			}, "/post/scheduled/{id}")
+
+			router.Delete("/post/scheduled/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", &handler.CancelScheduledPost{
+				PostRepository: postRepository,
+			}, "/post/scheduled/{id}")
+
+			router.Get("/post/feed", &handler.PostFeed{
+				PostRepository:        postRepository,
+				UserRepository:        userRepository,
+				PostFeedCache:         postFeedCache,
+				PostCache:             postCache,
+				PostFeedBuilder:       postFeedBuilder,
+				PostVisibilityChecker: postVisibilityChecker,
+				PostHydrator:          postHydrator,
+				PostViewRecorder:      postViewRecorder,
+				PostRankerSelector:    postRankerSelector,
+				EnvConfig:             envConfig,
 			}, "/post/feed")
 
+			router.Get(`/post/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/stats`, &handler.PostStats{
+				PostRepository:         postRepository,
+				PostReactionRepository: postReactionRepository,
+				PostStatsRepository:    postStatsRepository,
+				PostViewCache:          postViewCache,
+			}, "/post/{id}/stats")
+
+			router.Put(`/post/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/react`, &handler.ReactPost{
+				PostRepository:         postRepository,
+				PostVisibilityChecker:  postVisibilityChecker,
+				PostReactionRepository: postReactionRepository,
+				PostReactionCache:      postReactionCache,
+				RMQ:                    rabbitMQ,
+			}, "/post/{id}/react")
+
+			router.Delete(`/post/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/react`, &handler.DeletePostReaction{
+				PostReactionRepository: postReactionRepository,
+				PostReactionCache:      postReactionCache,
+			}, "/post/{id}/react")
+
+			router.Post(`/post/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/comments`, &handler.CreateComment{
+				PostRepository:        postRepository,
+				CommentRepository:     commentRepository,
+				PostVisibilityChecker: postVisibilityChecker,
+				ModerationPipeline:    moderationPipeline,
+				PostCache:             postCache,
+				RMQ:                   rabbitMQ,
+			}, "/post/{id}/comments")
+
+			router.Get(`/post/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/comments`, &handler.ListComments{
+				PostRepository:        postRepository,
+				CommentRepository:     commentRepository,
+				PostVisibilityChecker: postVisibilityChecker,
+			}, "/post/{id}/comments")
+
+			router.Put(`/comment/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}`, &handler.UpdateComment{
+				CommentRepository:  commentRepository,
+				ModerationPipeline: moderationPipeline,
+			}, "/comment/{id}")
+
+			router.Delete(`/comment/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}`, &handler.DeleteComment{
+				PostRepository:    postRepository,
+				CommentRepository: commentRepository,
+				PostCache:         postCache,
+			}, "/comment/{id}")
+
+			router.Post(`/post/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/share`, &handler.SharePost{
+				PostRepository:        postRepository,
+				UserRepository:        userRepository,
+				PostVisibilityChecker: postVisibilityChecker,
+				ModerationPipeline:    moderationPipeline,
+				PostCache:             postCache,
+				RMQ:                   rabbitMQ,
+			}, "/post/{id}/share")
+
+			router.Get(`/tag/{name:[^\x2f]+}/posts`, &handler.TagPosts{
+				PostRepository:        postRepository,
+				PostVisibilityChecker: postVisibilityChecker,
+				PostHydrator:          postHydrator,
+			}, "/tag/{name}/posts")
+
+			router.Post(`/post/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/poll/vote`, &handler.VotePoll{
+				PostRepository:        postRepository,
+				PostVisibilityChecker: postVisibilityChecker,
+				PollRepository:        pollRepository,
+				PostPollCache:         postPollCache,
+			}, "/post/{id}/poll/vote")
+
+			router.Put(`/post/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/bookmark`, &handler.BookmarkPost{
+				PostRepository:        postRepository,
+				PostVisibilityChecker: postVisibilityChecker,
+				BookmarkRepository:    bookmarkRepository,
+			}, "/post/{id}/bookmark")
+
+			router.Delete(`/post/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/bookmark`, &handler.DeleteBookmark{
+				BookmarkRepository: bookmarkRepository,
+			}, "/post/{id}/bookmark")
+
+			router.Get("/bookmarks", &handler.Bookmarks{
+				BookmarkRepository:    bookmarkRepository,
+				PostRepository:        postRepository,
+				PostVisibilityChecker: postVisibilityChecker,
+				PostHydrator:          postHydrator,
+			}, "/bookmarks")
+
+			router.Get("/post/search", &handler.SearchPosts{
+				PostRepository: postRepository,
+				PostHydrator:   postHydrator,
+			}, "/post/search")
+
+			router.Get(`/user/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/posts`, &handler.UserPosts{
+				UserRepository:        userRepository,
+				PostRepository:        postRepository,
+				PostVisibilityChecker: postVisibilityChecker,
+				PostHydrator:          postHydrator,
+			}, "/user/{id}/posts")
+
+			router.Post("/report", &handler.CreateReport{
+				ReportRepository:      reportRepository,
+				PostRepository:        postRepository,
+				CommentRepository:     commentRepository,
+				UserRepository:        userRepository,
+				DialogRepository:      dialogRepository,
+				PostVisibilityChecker: postVisibilityChecker,
+				PostCache:             postCache,
+				RMQ:                   rabbitMQ,
+				EnvConfig:             envConfig,
+			}, "/report")
+
 			router.Post(`/dialog/{user_id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/send`, &handler.SendDialog{
-				DialogRepository: dialogRepository,
+				DialogRepository:   dialogRepository,
+				ModerationPipeline: moderationPipeline,
 			}, "/dialog/{user_id}/send")
 
 			router.Get(`/dialog/{user_id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/list`, &handler.ListDialog{
 				DialogRepository: dialogRepository,
 			}, "/dialog/{user_id}/list")
+
+			router.Group(func(router httprouter.Router) {
+				router.Use(apiv1middleware.NewAdmin())
+
+				router.Get("/admin/moderation/posts", &handler.HeldPosts{
+					PostRepository: postRepository,
+					PostHydrator:   postHydrator,
+				}, "/admin/moderation/posts")
+
+				router.Put(`/admin/moderation/posts/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/approve`, &handler.ApproveHeldPost{
+					PostRepository: postRepository,
+					RMQ:            rabbitMQ,
+				}, "/admin/moderation/posts/{id}/approve")
+
+				router.Put(`/admin/moderation/posts/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/reject`, &handler.RejectHeldPost{
+					PostRepository: postRepository,
+				}, "/admin/moderation/posts/{id}/reject")
+
+				router.Get("/admin/reports", &handler.Reports{
+					ReportRepository: reportRepository,
+				}, "/admin/reports")
+
+				router.Put(`/admin/reports/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/claim`, &handler.ClaimReport{
+					ReportRepository: reportRepository,
+				}, "/admin/reports/{id}/claim")
+
+				router.Put(`/admin/reports/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/resolve`, &handler.ResolveReport{
+					ReportRepository:  reportRepository,
+					PostRepository:    postRepository,
+					CommentRepository: commentRepository,
+					UserRepository:    userRepository,
+					PostCache:         postCache,
+					RMQ:               rabbitMQ,
+					EnvConfig:         envConfig,
+				}, "/admin/reports/{id}/resolve")
+
+				router.Post("/admin/user_stats/recompute", &handler.RecomputeUserStats{
+					UserStatsCounter: userStatsCounter,
+				}, "/admin/user_stats/recompute")
+
+				router.Get("/admin/fanout/stats", &handler.FanoutStats{
+					PostFanoutService: postFanoutService,
+				}, "/admin/fanout/stats")
+
+				router.Get("/admin/feeds/stats", &handler.FeedStats{
+					PostFeedCache: postFeedCache,
+					EnvConfig:     envConfig,
+				}, "/admin/feeds/stats")
+			})
 		})
 	})
 
@@ -316,6 +637,82 @@ func run() error {
 	return nil
 }
 
+func writeDBConfig(envConfig *config.EnvConfig) db.Config {
+	return db.Config{
+		DriverName:         envConfig.WriteDBDriverName,
+		Host:               envConfig.WriteDBHost,
+		Port:               envConfig.WriteDBPort,
+		Username:           envConfig.WriteDBUsername,
+		Password:           envConfig.WriteDBPassword,
+		DBName:             envConfig.WriteDBName,
+		SSLMode:            envConfig.WriteDBSSLMode,
+		MaxOpenConnections: envConfig.WriteDBMaxOpenConnections,
+		MigrationPath:      "./storage/migrations",
+	}
+}
+
+func readDBConfig(envConfig *config.EnvConfig) db.Config {
+	return db.Config{
+		DriverName:         envConfig.ReadDBDriverName,
+		Host:               envConfig.ReadDBHost,
+		Port:               envConfig.ReadDBPort,
+		Username:           envConfig.ReadDBUsername,
+		Password:           envConfig.ReadDBPassword,
+		DBName:             envConfig.ReadDBName,
+		SSLMode:            envConfig.ReadDBSSLMode,
+		MaxOpenConnections: envConfig.ReadDBMaxOpenConnections,
+	}
+}
+
+func redisConfig(envConfig *config.EnvConfig) *rdb.Config {
+	return &rdb.Config{
+		Host:     envConfig.RedisHost,
+		Port:     envConfig.RedisPort,
+		Password: envConfig.RedisPassword,
+		DBNum:    envConfig.RedisDBNum,
+	}
+}
+
+// newPostRankerSelector makes the rankers of the top post feed listed in the config, the users are split between them.
+func newPostRankerSelector(envConfig *config.EnvConfig, postRepository repository.PostRepository,
+	userRepository repository.UserRepository, postReactionRepository repository.PostReactionRepository,
+	commentRepository repository.CommentRepository, dialogRepository repository.DialogRepository,
+	postReactionCache *postreactioncache.Cache,
+) (*postranker.Selector, error) {
+	recencySignal := postranker.NewRecencySignal(time.Duration(envConfig.FeedTopRecencyHalfLifeHours) * time.Hour)
+	affinitySignal := postranker.NewAffinitySignal(userRepository, dialogRepository)
+	engagementSignal := postranker.NewEngagementSignal(postRepository, postReactionRepository, commentRepository, postReactionCache)
+
+	availableRankers := map[string]postranker.Ranker{
+		"weighted": postranker.NewWeightedRanker("weighted",
+			postranker.WeightedSignal{Signal: recencySignal, Weight: envConfig.FeedTopRecencyWeight},
+			postranker.WeightedSignal{Signal: affinitySignal, Weight: envConfig.FeedTopAffinityWeight},
+			postranker.WeightedSignal{Signal: engagementSignal, Weight: envConfig.FeedTopEngagementWeight},
+		),
+		"weighted_no_affinity": postranker.NewWeightedRanker("weighted_no_affinity",
+			postranker.WeightedSignal{Signal: recencySignal, Weight: envConfig.FeedTopRecencyWeight},
+			postranker.WeightedSignal{Signal: engagementSignal, Weight: envConfig.FeedTopEngagementWeight},
+		),
+	}
+
+	rankers := make([]postranker.Ranker, 0, len(envConfig.FeedTopRankers))
+
+	for _, rankerName := range envConfig.FeedTopRankers {
+		ranker, ok := availableRankers[strings.TrimSpace(rankerName)]
+		if !ok {
+			return nil, fmt.Errorf("unknown feed top ranker %q", rankerName)
+		}
+
+		rankers = append(rankers, ranker)
+	}
+
+	if len(rankers) == 0 {
+		return nil, fmt.Errorf("no feed top rankers configured")
+	}
+
+	return postranker.NewSelector(rankers...), nil
+}
+
 func logLevel(lvl string) slog.Level {
 	switch lvl {
 	case "debug":
@@ -11,6 +11,9 @@ const (
 	entityNotFoundCode      = 102
 	invalidCredentialsCode  = 103
 	invalidTokenCode        = 104
+	contentRejectedCode     = 105
+	forbiddenCode           = 106
+	userBannedCode          = 107
 
 	ErrorLogLevelInfo    = "info"
 	ErrorLogLevelWarning = "warning"
@@ -109,3 +112,30 @@ func NewInvalidTokenError(text string, err error) *Error {
 		logLevel:   ErrorLogLevelInfo,
 	}
 }
+
+func NewContentRejectedError(reason string) *Error {
+	return &Error{
+		statusCode: http.StatusUnprocessableEntity,
+		message:    fmt.Sprintf("content rejected: %s", reason),
+		code:       contentRejectedCode,
+		logLevel:   ErrorLogLevelInfo,
+	}
+}
+
+func NewForbiddenError() *Error {
+	return &Error{
+		statusCode: http.StatusForbidden,
+		message:    "forbidden",
+		code:       forbiddenCode,
+		logLevel:   ErrorLogLevelInfo,
+	}
+}
+
+func NewUserBannedError() *Error {
+	return &Error{
+		statusCode: http.StatusForbidden,
+		message:    "user is banned",
+		code:       userBannedCode,
+		logLevel:   ErrorLogLevelInfo,
+	}
+}
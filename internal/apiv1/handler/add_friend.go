@@ -7,10 +7,12 @@ import (
 	"github.com/inbugay1/httprouter"
 	"myfacebook/internal/apiv1"
 	"myfacebook/internal/repository"
+	"myfacebook/internal/userstats"
 )
 
 type AddFriend struct {
-	UserRepository repository.UserRepository
+	UserRepository   repository.UserRepository
+	UserStatsCounter *userstats.Counter
 }
 
 func (h *AddFriend) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
@@ -25,6 +27,11 @@ func (h *AddFriend) Handle(responseWriter http.ResponseWriter, request *http.Req
 		return apiv1.NewServerError(fmt.Errorf("add friend handler, failed to add friend: %w", err))
 	}
 
+	err = h.UserStatsCounter.AddFollowers(ctx, friendID, 1)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("add friend handler, failed to count follower: %w", err))
+	}
+
 	responseWriter.Header().Set("Content-Type", "application/json; utf-8")
 	responseWriter.WriteHeader(http.StatusOK)
 
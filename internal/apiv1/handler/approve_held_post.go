@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/repository"
+	"myfacebook/internal/rmq"
+)
+
+type ApproveHeldPost struct {
+	PostRepository repository.PostRepository
+	RMQ            *rmq.RMQ
+}
+
+func (h *ApproveHeldPost) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	post, err := h.PostRepository.ApproveHeld(ctx, httprouter.RouteParam(ctx, "id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("approve held post handler, failed to approve post: %w", err))
+	}
+
+	// an approved post with publish time ahead is left to the post scheduler
+	if post.Status == repository.PostStatusPublished {
+		err = publishPostFeedRMQMessage(ctx, h.RMQ, "add", *post)
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("approve held post handler, %w", err))
+		}
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
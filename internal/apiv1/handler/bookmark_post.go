@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/postvisibility"
+	"myfacebook/internal/repository"
+)
+
+type BookmarkPost struct {
+	PostRepository        repository.PostRepository
+	PostVisibilityChecker *postvisibility.Checker
+	BookmarkRepository    repository.BookmarkRepository
+}
+
+func (h *BookmarkPost) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	post, err := h.PostRepository.GetPostByID(ctx, httprouter.RouteParam(ctx, "id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("bookmark post handler, failed to get post from repo: %w", err))
+	}
+
+	canView, err := h.PostVisibilityChecker.CanView(ctx, userID, *post)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("bookmark post handler, failed to check post visibility: %w", err))
+	}
+
+	if !canView {
+		return apiv1.NewEntityNotFoundError(nil)
+	}
+
+	err = h.BookmarkRepository.Add(ctx, userID, post.ID)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("bookmark post handler, failed to add bookmark: %w", err))
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/postvisibility"
+	"myfacebook/internal/repository"
+)
+
+const maxBookmarksLimit = 100
+
+type Bookmarks struct {
+	BookmarkRepository    repository.BookmarkRepository
+	PostRepository        repository.PostRepository
+	PostVisibilityChecker *postvisibility.Checker
+	PostHydrator          *PostHydrator
+}
+
+type bookmarksRequest struct {
+	Cursor *repository.Cursor
+	Limit  int
+}
+
+func (h *Bookmarks) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	bookmarksReq, err := h.getBookmarksRequest(request)
+	if err != nil {
+		return err
+	}
+
+	bookmarks, err := h.BookmarkRepository.GetBookmarksByUserID(ctx, userID, bookmarksReq.Cursor, bookmarksReq.Limit)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("bookmarks handler, failed to get bookmarks from repo: %w", err))
+	}
+
+	var posts []repository.Post
+
+	if len(bookmarks) > 0 {
+		postsIDs := make([]string, 0, len(bookmarks))
+
+		for _, bookmark := range bookmarks {
+			postsIDs = append(postsIDs, bookmark.PostID)
+		}
+
+		// deleted posts are not returned, so their bookmarks are skipped
+		postsByCreatedAt, err := h.PostRepository.GetPostsByIDs(ctx, postsIDs, 0, len(postsIDs))
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("bookmarks handler, failed to get posts by ids from repo: %w", err))
+		}
+
+		postsByID := make(map[string]repository.Post, len(postsByCreatedAt))
+
+		for _, post := range postsByCreatedAt {
+			postsByID[post.ID] = post
+		}
+
+		// keep the order the posts were saved in
+		for _, bookmark := range bookmarks {
+			if post, ok := postsByID[bookmark.PostID]; ok {
+				posts = append(posts, post)
+			}
+		}
+	}
+
+	visiblePosts, err := h.PostVisibilityChecker.FilterVisible(ctx, userID, posts)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("bookmarks handler, failed to filter visible posts: %w", err))
+	}
+
+	postsResponse, err := h.PostHydrator.hydrate(ctx, userID, visiblePosts)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("bookmarks handler, failed to hydrate posts: %w", err))
+	}
+
+	bookmarksResp := postsPageResponse{
+		Posts: postsResponse,
+	}
+
+	if len(bookmarks) == bookmarksReq.Limit {
+		lastBookmark := bookmarks[len(bookmarks)-1]
+
+		bookmarksResp.NextCursor = encodeCursor(repository.Cursor{
+			CreatedAt: lastBookmark.CreatedAt,
+			ID:        lastBookmark.PostID,
+		})
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(&bookmarksResp)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("bookmarks handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
+
+func (h *Bookmarks) getBookmarksRequest(request *http.Request) (bookmarksRequest, error) {
+	bookmarksReq := bookmarksRequest{
+		Limit: 10,
+	}
+
+	if cursor := request.URL.Query().Get("cursor"); cursor != "" {
+		decodedCursor, err := decodeCursor(cursor)
+		if err != nil {
+			return bookmarksReq, apiv1.NewInvalidRequestErrorInvalidParameter("cursor",
+				fmt.Errorf("bookmarks handler, failed to decode cursor %q: %w", cursor, err))
+		}
+
+		bookmarksReq.Cursor = decodedCursor
+	}
+
+	if request.URL.Query().Get("limit") != "" {
+		limit, err := strconv.Atoi(request.URL.Query().Get("limit"))
+		if err != nil {
+			return bookmarksReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit",
+				fmt.Errorf("bookmarks handler, failed to convert limit %q to int: %w", request.URL.Query().Get("limit"), err))
+		}
+
+		if limit <= 0 || limit > maxBookmarksLimit {
+			return bookmarksReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit", nil)
+		}
+
+		bookmarksReq.Limit = limit
+	}
+
+	return bookmarksReq, nil
+}
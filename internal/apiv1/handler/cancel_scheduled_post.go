@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/repository"
+)
+
+type CancelScheduledPost struct {
+	PostRepository repository.PostRepository
+}
+
+func (h *CancelScheduledPost) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	authorID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	err := h.PostRepository.CancelScheduled(ctx, httprouter.RouteParam(ctx, "id"), authorID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("cancel scheduled post handler, failed to cancel scheduled post: %w", err))
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
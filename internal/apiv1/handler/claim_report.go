@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/repository"
+)
+
+type ClaimReport struct {
+	ReportRepository repository.ReportRepository
+}
+
+func (h *ClaimReport) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	err := h.ReportRepository.Claim(ctx, httprouter.RouteParam(ctx, "id"), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("claim report handler, failed to claim report: %w", err))
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofrs/uuid"
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/moderation"
+	"myfacebook/internal/postcache"
+	"myfacebook/internal/postvisibility"
+	"myfacebook/internal/repository"
+	"myfacebook/internal/rmq"
+)
+
+type CreateComment struct {
+	PostRepository        repository.PostRepository
+	CommentRepository     repository.CommentRepository
+	PostVisibilityChecker *postvisibility.Checker
+	ModerationPipeline    *moderation.Pipeline
+	PostCache             *postcache.Cache
+	RMQ                   *rmq.RMQ
+}
+
+type createCommentRequest struct {
+	ParentID string `json:"parent_id"`
+	Text     string `json:"text"`
+}
+
+type createCommentResponse struct {
+	ID string `json:"id"`
+}
+
+type commentCreatedRMQMessage struct {
+	CommentID string `json:"comment_id"`
+	PostID    string `json:"post_id"`
+	ParentID  string `json:"parent_id,omitempty"`
+	AuthorID  string `json:"author_id"`
+	Text      string `json:"text"`
+}
+
+func (h *CreateComment) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	var createCommentReq createCommentRequest
+	if err := json.NewDecoder(request.Body).Decode(&createCommentReq); err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create comment handler, cannot decode request body: %w", err))
+	}
+
+	defer request.Body.Close()
+
+	if createCommentReq.Text == "" {
+		return apiv1.NewInvalidRequestErrorMissingRequiredParameter("text")
+	}
+
+	ctx := request.Context()
+
+	authorID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	postID := httprouter.RouteParam(ctx, "id")
+
+	post, err := h.PostRepository.GetPostByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("create comment handler, failed to get post from repo: %w", err))
+	}
+
+	canView, err := h.PostVisibilityChecker.CanView(ctx, authorID, *post)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create comment handler, failed to check post visibility: %w", err))
+	}
+
+	if !canView {
+		return apiv1.NewEntityNotFoundError(nil)
+	}
+
+	var parentComment *repository.Comment
+
+	if createCommentReq.ParentID != "" {
+		if _, err := uuid.FromString(createCommentReq.ParentID); err != nil {
+			return apiv1.NewInvalidRequestErrorInvalidParameter("parent_id", err)
+		}
+
+		parentComment, err = h.CommentRepository.GetCommentByID(ctx, createCommentReq.ParentID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewServerError(fmt.Errorf("create comment handler, failed to get parent comment from repo: %w", err))
+		}
+
+		if parentComment == nil || parentComment.PostID != post.ID {
+			return apiv1.NewInvalidRequestErrorInvalidParameter("parent_id", err)
+		}
+	}
+
+	moderationContent := moderation.Content{
+		Kind:     moderation.ContentKindComment,
+		AuthorID: authorID,
+		Text:     createCommentReq.Text,
+	}
+
+	err = moderateWithoutReview(ctx, h.ModerationPipeline, moderationContent)
+	if err != nil {
+		return err
+	}
+
+	commentUUIDv4, err := uuid.NewV4()
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create comment handler, failed to generate comment uuid: %w", err))
+	}
+
+	comment := repository.Comment{
+		ID:       commentUUIDv4.String(),
+		PostID:   post.ID,
+		ParentID: createCommentReq.ParentID,
+		AuthorID: authorID,
+		Text:     createCommentReq.Text,
+	}
+
+	err = h.CommentRepository.Add(ctx, comment)
+	if err != nil {
+		return releaseModerated(ctx, h.ModerationPipeline, moderationContent,
+			fmt.Errorf("create comment handler, failed to add comment: %w", err))
+	}
+
+	err = h.PostCache.DeleteCounters(ctx, post.ID)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create comment handler, %w", err))
+	}
+
+	commentCreatedRMQMsg, err := json.Marshal(commentCreatedRMQMessage{
+		CommentID: comment.ID,
+		PostID:    comment.PostID,
+		ParentID:  comment.ParentID,
+		AuthorID:  comment.AuthorID,
+		Text:      comment.Text,
+	})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create comment handler, failed to make rmq message: %w", err))
+	}
+
+	err = h.RMQ.Publish(ctx, "/post/comments/created", post.ID, commentCreatedRMQMsg)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create comment handler, failed to publish rmq message: %w", err))
+	}
+
+	err = publishNotification(ctx, h.RMQ, post.AuthorID, notificationRMQMessage{
+		Type:      "post_comment",
+		ActorID:   authorID,
+		PostID:    post.ID,
+		CommentID: comment.ID,
+	})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create comment handler, %w", err))
+	}
+
+	if parentComment != nil && parentComment.AuthorID != post.AuthorID {
+		err = publishNotification(ctx, h.RMQ, parentComment.AuthorID, notificationRMQMessage{
+			Type:      "comment_reply",
+			ActorID:   authorID,
+			PostID:    post.ID,
+			CommentID: comment.ID,
+		})
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("create comment handler, %w", err))
+		}
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(createCommentResponse{
+		ID: comment.ID,
+	})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create comment handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
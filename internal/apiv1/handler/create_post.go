@@ -1,37 +1,66 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"myfacebook/internal/apiv1"
+	"myfacebook/internal/moderation"
+	"myfacebook/internal/postpollcache"
+	"myfacebook/internal/postvisibility"
 	"myfacebook/internal/repository"
 	"myfacebook/internal/rmq"
 )
 
 var errUserIDTypeAssertionFailed = errors.New("failed to assert user_id to string")
 
+const (
+	minPollOptions = 2
+	maxPollOptions = 10
+)
+
 type CreatePost struct {
-	PostRepository repository.PostRepository
-	RMQ            *rmq.RMQ
+	PostRepository     repository.PostRepository
+	UserRepository     repository.UserRepository
+	PollRepository     repository.PollRepository
+	ModerationPipeline *moderation.Pipeline
+	PostPollCache      *postpollcache.Cache
+	RMQ                *rmq.RMQ
 }
 
 type postRequest struct {
-	Text string `json:"text"`
+	Text       string       `json:"text"`
+	Visibility string       `json:"visibility"`
+	Audience   []string     `json:"audience"`
+	PublishAt  *time.Time   `json:"publish_at"`
+	Poll       *pollRequest `json:"poll"`
+}
+
+type pollRequest struct {
+	Options        []string   `json:"options"`
+	MultipleChoice bool       `json:"multiple_choice"`
+	ClosesAt       *time.Time `json:"closes_at"`
 }
 
 type postResponse struct {
-	ID string `json:"id"`
+	ID     string `json:"id"`
+	Status string `json:"status,omitempty"`
 }
 
 type postFeedRMQMessage struct {
-	Operation string `json:"operation"`
-	PostID    string `json:"post_id"`
-	PostText  string `json:"post_text,omitempty"`
-	AuthorID  string `json:"author_id"`
+	Operation      string     `json:"operation"`
+	PostID         string     `json:"post_id"`
+	PostText       string     `json:"post_text,omitempty"`
+	AuthorID       string     `json:"author_id"`
+	OriginalPostID string     `json:"original_post_id,omitempty"`
+	Visibility     string     `json:"visibility,omitempty"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
 }
 
 func (h *CreatePost) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
@@ -42,10 +71,6 @@ func (h *CreatePost) Handle(responseWriter http.ResponseWriter, request *http.Re
 
 	defer request.Body.Close()
 
-	if postReq.Text == "" {
-		return apiv1.NewInvalidRequestErrorMissingRequiredParameter("text")
-	}
-
 	ctx := request.Context()
 
 	authorID, ok := ctx.Value("user_id").(string)
@@ -53,46 +78,222 @@ func (h *CreatePost) Handle(responseWriter http.ResponseWriter, request *http.Re
 		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
 	}
 
+	post, err := h.create(ctx, authorID, postReq)
+	if err != nil {
+		return err
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(postResponse{
+		ID:     post.ID,
+		Status: post.Status,
+	})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create post handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
+
+// create validates the post request, stores the post and publishes it to the followers' feeds.
+func (h *CreatePost) create(ctx context.Context, authorID string, postReq postRequest) (*repository.Post, error) {
+	if postReq.Text == "" {
+		return nil, apiv1.NewInvalidRequestErrorMissingRequiredParameter("text")
+	}
+
+	if postReq.Visibility == "" {
+		postReq.Visibility = repository.PostVisibilityPublic
+	}
+
+	if err := validatePostVisibility(postReq.Visibility, postReq.Audience); err != nil {
+		return nil, err
+	}
+
+	if postReq.Poll != nil {
+		if err := validatePoll(*postReq.Poll); err != nil {
+			return nil, err
+		}
+	}
+
+	moderationContent := moderation.Content{
+		Kind:     moderation.ContentKindPost,
+		AuthorID: authorID,
+		Text:     postReq.Text,
+	}
+
+	verdict, err := moderate(ctx, h.ModerationPipeline, moderationContent)
+	if err != nil {
+		return nil, err
+	}
+
 	postUUIDv4, err := uuid.NewV4()
 	if err != nil {
-		return apiv1.NewServerError(fmt.Errorf("create post handler, failed to generate user uuid: %w", err))
+		return nil, apiv1.NewServerError(fmt.Errorf("create post handler, failed to generate user uuid: %w", err))
 	}
 
 	post := repository.Post{
-		ID:       postUUIDv4.String(),
-		Text:     postReq.Text,
-		AuthorID: authorID,
+		ID:         postUUIDv4.String(),
+		Text:       postReq.Text,
+		AuthorID:   authorID,
+		Visibility: postReq.Visibility,
+		Status:     repository.PostStatusPublished,
+		CreatedAt:  newPostCreatedAt(),
+	}
+
+	if postReq.PublishAt != nil && postReq.PublishAt.After(time.Now()) {
+		publishAt := postReq.PublishAt.UTC()
+
+		post.Status = repository.PostStatusScheduled
+		post.PublishAt = &publishAt
+	}
+
+	// a held post keeps its publish time, it is scheduled once approved
+	if verdict == moderation.VerdictHold {
+		post.Status = repository.PostStatusHeld
+		post.HeldReason = repository.PostHeldReasonModeration
 	}
 
 	err = h.PostRepository.Add(ctx, post)
 	if err != nil {
-		return apiv1.NewServerError(fmt.Errorf("create post handler, failed to add post: %w", err))
+		return nil, releaseModerated(ctx, h.ModerationPipeline, moderationContent,
+			fmt.Errorf("create post handler, failed to add post: %w", err))
 	}
 
+	if post.Visibility == repository.PostVisibilityList {
+		err = h.PostRepository.SetAudience(ctx, post.ID, postReq.Audience)
+		if err != nil {
+			return nil, apiv1.NewServerError(fmt.Errorf("create post handler, failed to set post audience: %w", err))
+		}
+	}
+
+	if postReq.Poll != nil {
+		poll := newPoll(post.ID, *postReq.Poll)
+
+		err = h.PollRepository.Add(ctx, poll)
+		if err != nil {
+			return nil, apiv1.NewServerError(fmt.Errorf("create post handler, failed to add poll: %w", err))
+		}
+
+		// the post may have been read and cached as having no poll before the poll is added
+		err = h.PostPollCache.SetPolls(ctx, []string{post.ID}, map[string]repository.Poll{post.ID: poll})
+		if err != nil {
+			return nil, apiv1.NewServerError(fmt.Errorf("create post handler, %w", err))
+		}
+	}
+
+	err = savePostEntities(ctx, h.PostRepository, h.UserRepository, h.RMQ, authorID, post)
+	if err != nil {
+		return nil, apiv1.NewServerError(fmt.Errorf("create post handler, %w", err))
+	}
+
+	// scheduled posts are fanned out by the post scheduler, held ones once approved
+	if post.Status == repository.PostStatusPublished {
+		err = publishPostFeedRMQMessage(ctx, h.RMQ, "add", post)
+		if err != nil {
+			return nil, apiv1.NewServerError(fmt.Errorf("create post handler, %w", err))
+		}
+	}
+
+	return &post, nil
+}
+
+func publishPostFeedRMQMessage(ctx context.Context, rabbitMQ *rmq.RMQ, operation string, post repository.Post) error {
 	postFeedRMQMsg, err := json.Marshal(postFeedRMQMessage{
-		Operation: "add",
-		PostID:    post.ID,
-		PostText:  post.Text,
-		AuthorID:  authorID,
+		Operation:      operation,
+		PostID:         post.ID,
+		PostText:       post.Text,
+		AuthorID:       post.AuthorID,
+		OriginalPostID: post.OriginalPostID,
+		Visibility:     post.Visibility,
+		CreatedAt:      nonZeroTime(post.CreatedAt),
 	})
 	if err != nil {
-		return apiv1.NewServerError(fmt.Errorf("create post handler, failed to make rmq message: %w", err))
+		return fmt.Errorf("failed to make rmq message: %w", err)
 	}
 
-	err = h.RMQ.Publish(ctx, "", "/post/feed", postFeedRMQMsg)
+	err = rabbitMQ.Publish(ctx, "", "/post/feed", postFeedRMQMsg)
 	if err != nil {
-		return apiv1.NewServerError(fmt.Errorf("create post handler, failed to publish rmq message: %w", err))
+		return fmt.Errorf("failed to publish rmq message: %w", err)
 	}
 
-	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
-	responseWriter.WriteHeader(http.StatusOK)
+	return nil
+}
 
-	err = json.NewEncoder(responseWriter).Encode(postResponse{
-		ID: post.ID,
-	})
-	if err != nil {
-		return apiv1.NewServerError(fmt.Errorf("create post handler, cannot encode response: %w", err))
+// newPostCreatedAt gives the creation time of a new post. It is cut to the db precision,
+// so the time cached with the post matches the stored one.
+func newPostCreatedAt() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// nonZeroTime is for optional json times, a removed post is published by id only.
+func nonZeroTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+func validatePostVisibility(visibility string, audience []string) error {
+	if !postvisibility.IsValid(visibility) {
+		return apiv1.NewInvalidRequestErrorInvalidParameter("visibility", nil)
+	}
+
+	if visibility != repository.PostVisibilityList {
+		return nil
+	}
+
+	if len(audience) == 0 {
+		return apiv1.NewInvalidRequestErrorMissingRequiredParameter("audience")
+	}
+
+	for _, userID := range audience {
+		if _, err := uuid.FromString(userID); err != nil {
+			return apiv1.NewInvalidRequestErrorInvalidParameter("audience", err)
+		}
 	}
 
 	return nil
 }
+
+func validatePoll(pollReq pollRequest) error {
+	if len(pollReq.Options) < minPollOptions || len(pollReq.Options) > maxPollOptions {
+		return apiv1.NewInvalidRequestErrorInvalidParameter("poll.options", nil)
+	}
+
+	for _, option := range pollReq.Options {
+		if strings.TrimSpace(option) == "" {
+			return apiv1.NewInvalidRequestErrorInvalidParameter("poll.options", nil)
+		}
+	}
+
+	if pollReq.ClosesAt != nil && !pollReq.ClosesAt.After(time.Now()) {
+		return apiv1.NewInvalidRequestErrorInvalidParameter("poll.closes_at", nil)
+	}
+
+	return nil
+}
+
+func newPoll(postID string, pollReq pollRequest) repository.Poll {
+	poll := repository.Poll{
+		PostID:         postID,
+		MultipleChoice: pollReq.MultipleChoice,
+		Options:        make([]repository.PollOption, 0, len(pollReq.Options)),
+	}
+
+	if pollReq.ClosesAt != nil {
+		closesAt := pollReq.ClosesAt.UTC()
+		poll.ClosesAt = &closesAt
+	}
+
+	for i, option := range pollReq.Options {
+		poll.Options = append(poll.Options, repository.PollOption{
+			ID:   i,
+			Text: strings.TrimSpace(option),
+		})
+	}
+
+	return poll
+}
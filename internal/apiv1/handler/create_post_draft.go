@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/config"
+	"myfacebook/internal/postvisibility"
+	"myfacebook/internal/repository"
+)
+
+type CreatePostDraft struct {
+	PostDraftRepository repository.PostDraftRepository
+	EnvConfig           *config.EnvConfig
+}
+
+type postDraftRequest struct {
+	Text       string   `json:"text"`
+	Visibility string   `json:"visibility"`
+	Audience   []string `json:"audience"`
+}
+
+func (h *CreatePostDraft) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	var postDraftReq postDraftRequest
+	if err := json.NewDecoder(request.Body).Decode(&postDraftReq); err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create post draft handler, cannot decode request body: %w", err))
+	}
+
+	defer request.Body.Close()
+
+	if err := validatePostDraftRequest(postDraftReq); err != nil {
+		return err
+	}
+
+	ctx := request.Context()
+
+	authorID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	draftUUIDv4, err := uuid.NewV4()
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create post draft handler, failed to generate draft uuid: %w", err))
+	}
+
+	draft := repository.PostDraft{
+		ID:         draftUUIDv4.String(),
+		AuthorID:   authorID,
+		Text:       postDraftReq.Text,
+		Visibility: postDraftReq.Visibility,
+		Audience:   postDraftReq.Audience,
+	}
+
+	err = h.PostDraftRepository.Add(ctx, draft, time.Duration(h.EnvConfig.PostDraftTTLHours)*time.Hour)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create post draft handler, failed to add post draft: %w", err))
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(postResponse{
+		ID: draft.ID,
+	})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create post draft handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
+
+// validatePostDraftRequest checks only what is filled in, a draft may be incomplete until it is published.
+func validatePostDraftRequest(postDraftReq postDraftRequest) error {
+	if postDraftReq.Visibility != "" && !postvisibility.IsValid(postDraftReq.Visibility) {
+		return apiv1.NewInvalidRequestErrorInvalidParameter("visibility", nil)
+	}
+
+	for _, userID := range postDraftReq.Audience {
+		if _, err := uuid.FromString(userID); err != nil {
+			return apiv1.NewInvalidRequestErrorInvalidParameter("audience", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/gofrs/uuid"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/config"
+	"myfacebook/internal/postcache"
+	"myfacebook/internal/postvisibility"
+	"myfacebook/internal/repository"
+	"myfacebook/internal/rmq"
+)
+
+const maxReportCommentLength = 1000
+
+var reportReasons = map[string]struct{}{
+	"spam":           {},
+	"harassment":     {},
+	"hate_speech":    {},
+	"violence":       {},
+	"nudity":         {},
+	"misinformation": {},
+	"other":          {},
+}
+
+type CreateReport struct {
+	ReportRepository      repository.ReportRepository
+	PostRepository        repository.PostRepository
+	CommentRepository     repository.CommentRepository
+	UserRepository        repository.UserRepository
+	DialogRepository      repository.DialogRepository
+	PostVisibilityChecker *postvisibility.Checker
+	PostCache             *postcache.Cache
+	RMQ                   *rmq.RMQ
+	EnvConfig             *config.EnvConfig
+}
+
+type createReportRequest struct {
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	UserID  string `json:"user_id"`
+	Reason  string `json:"reason"`
+	Comment string `json:"comment"`
+}
+
+func (h *CreateReport) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	var createReportReq createReportRequest
+	if err := json.NewDecoder(request.Body).Decode(&createReportReq); err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create report handler, cannot decode request body: %w", err))
+	}
+
+	defer request.Body.Close()
+
+	if err := h.validateCreateReportRequest(createReportReq); err != nil {
+		return err
+	}
+
+	ctx := request.Context()
+
+	reporterID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	targetAuthorID, err := h.getTargetAuthorID(ctx, reporterID, createReportReq)
+	if err != nil {
+		return err
+	}
+
+	if targetAuthorID == reporterID {
+		return apiv1.NewInvalidRequestErrorInvalidParameter("id", nil)
+	}
+
+	reportUUIDv4, err := uuid.NewV4()
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create report handler, failed to generate report uuid: %w", err))
+	}
+
+	err = h.ReportRepository.Add(ctx, repository.Report{
+		ID:             reportUUIDv4.String(),
+		TargetType:     createReportReq.Type,
+		TargetID:       createReportReq.ID,
+		TargetAuthorID: targetAuthorID,
+		ReporterID:     reporterID,
+		Reason:         createReportReq.Reason,
+		Comment:        createReportReq.Comment,
+	})
+	if err != nil {
+		// the same target reported twice by the same user counts once
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			responseWriter.WriteHeader(http.StatusOK)
+
+			return nil
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("create report handler, failed to add report: %w", err))
+	}
+
+	reportsCount, err := h.ReportRepository.GetPendingCountByTarget(ctx, createReportReq.Type, createReportReq.ID)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("create report handler, %w", err))
+	}
+
+	if reportsCount >= h.EnvConfig.ReportHideThreshold {
+		err = h.hideTarget(ctx, createReportReq.Type, createReportReq.ID)
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("create report handler, %w", err))
+		}
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
+
+func (h *CreateReport) validateCreateReportRequest(createReportReq createReportRequest) error {
+	switch createReportReq.Type {
+	case "":
+		return apiv1.NewInvalidRequestErrorMissingRequiredParameter("type")
+	case repository.ReportTargetPost, repository.ReportTargetComment, repository.ReportTargetUser, repository.ReportTargetMessage:
+	default:
+		return apiv1.NewInvalidRequestErrorInvalidParameter("type", nil)
+	}
+
+	if createReportReq.ID == "" {
+		return apiv1.NewInvalidRequestErrorMissingRequiredParameter("id")
+	}
+
+	if createReportReq.Type != repository.ReportTargetMessage {
+		if _, err := uuid.FromString(createReportReq.ID); err != nil {
+			return apiv1.NewInvalidRequestErrorInvalidParameter("id", err)
+		}
+	} else {
+		if createReportReq.UserID == "" {
+			return apiv1.NewInvalidRequestErrorMissingRequiredParameter("user_id")
+		}
+
+		if _, err := uuid.FromString(createReportReq.UserID); err != nil {
+			return apiv1.NewInvalidRequestErrorInvalidParameter("user_id", err)
+		}
+	}
+
+	if createReportReq.Reason == "" {
+		return apiv1.NewInvalidRequestErrorMissingRequiredParameter("reason")
+	}
+
+	if _, ok := reportReasons[createReportReq.Reason]; !ok {
+		return apiv1.NewInvalidRequestErrorInvalidParameter("reason", nil)
+	}
+
+	if utf8.RuneCountInString(createReportReq.Comment) > maxReportCommentLength {
+		return apiv1.NewInvalidRequestErrorInvalidParameter("comment", nil)
+	}
+
+	return nil
+}
+
+// getTargetAuthorID makes sure the reporter can see the reported target and returns the user responsible for it.
+func (h *CreateReport) getTargetAuthorID(ctx context.Context, reporterID string, createReportReq createReportRequest) (string, error) {
+	switch createReportReq.Type {
+	case repository.ReportTargetPost:
+		return h.getPostAuthorID(ctx, reporterID, createReportReq.ID)
+	case repository.ReportTargetComment:
+		comment, err := h.CommentRepository.GetCommentByID(ctx, createReportReq.ID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return "", apiv1.NewEntityNotFoundError(err)
+			}
+
+			return "", apiv1.NewServerError(fmt.Errorf("create report handler, failed to get comment from repo: %w", err))
+		}
+
+		if _, err := h.getPostAuthorID(ctx, reporterID, comment.PostID); err != nil {
+			return "", err
+		}
+
+		return comment.AuthorID, nil
+	case repository.ReportTargetUser:
+		user, err := h.UserRepository.GetUserByID(ctx, createReportReq.ID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return "", apiv1.NewEntityNotFoundError(err)
+			}
+
+			return "", apiv1.NewServerError(fmt.Errorf("create report handler, failed to get user from repo: %w", err))
+		}
+
+		return user.ID, nil
+	default:
+		// only a received message can be reported
+		dialogMessages, err := h.DialogRepository.GetDialogMessagesBySenderIDAndReceiverID(ctx, reporterID, createReportReq.UserID)
+		if err != nil {
+			return "", apiv1.NewServerError(fmt.Errorf("create report handler, failed to get dialog messages: %w", err))
+		}
+
+		for _, dialogMessage := range dialogMessages {
+			if dialogMessage.ID == createReportReq.ID && dialogMessage.From == createReportReq.UserID {
+				return dialogMessage.From, nil
+			}
+		}
+
+		return "", apiv1.NewEntityNotFoundError(nil)
+	}
+}
+
+func (h *CreateReport) getPostAuthorID(ctx context.Context, reporterID, postID string) (string, error) {
+	post, err := h.PostRepository.GetPostByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", apiv1.NewEntityNotFoundError(err)
+		}
+
+		return "", apiv1.NewServerError(fmt.Errorf("create report handler, failed to get post from repo: %w", err))
+	}
+
+	canView, err := h.PostVisibilityChecker.CanView(ctx, reporterID, *post)
+	if err != nil {
+		return "", apiv1.NewServerError(fmt.Errorf("create report handler, failed to check post visibility: %w", err))
+	}
+
+	if !canView {
+		return "", apiv1.NewEntityNotFoundError(nil)
+	}
+
+	return post.AuthorID, nil
+}
+
+// hideTarget takes the content off until its reports are reviewed. Users and dialog messages are not hidden.
+func (h *CreateReport) hideTarget(ctx context.Context, targetType, targetID string) error {
+	switch targetType {
+	case repository.ReportTargetPost:
+		post, err := h.PostRepository.Hold(ctx, targetID)
+		if err != nil {
+			// already held or deleted
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil
+			}
+
+			return fmt.Errorf("failed to hold reported post: %w", err)
+		}
+
+		return publishPostFeedRMQMessage(ctx, h.RMQ, "remove", *post)
+	case repository.ReportTargetComment:
+		comment, err := h.CommentRepository.GetCommentByID(ctx, targetID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil
+			}
+
+			return fmt.Errorf("failed to get reported comment: %w", err)
+		}
+
+		err = h.CommentRepository.Hide(ctx, targetID)
+		if err != nil {
+			return fmt.Errorf("failed to hide reported comment: %w", err)
+		}
+
+		err = h.PostCache.DeleteCounters(ctx, comment.PostID)
+		if err != nil {
+			return fmt.Errorf("failed to drop counters of reported comment post: %w", err)
+		}
+	}
+
+	return nil
+}
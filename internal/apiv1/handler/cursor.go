@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"myfacebook/internal/repository"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+func encodeCursor(cursor repository.Cursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", cursor.CreatedAt.UnixMicro(), cursor.ID)))
+}
+
+func decodeCursor(encodedCursor string) (*repository.Cursor, error) {
+	decodedCursor, err := base64.RawURLEncoding.DecodeString(encodedCursor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cursor: %w", err)
+	}
+
+	createdAt, id, found := strings.Cut(string(decodedCursor), ":")
+	if !found || id == "" {
+		return nil, errInvalidCursor
+	}
+
+	createdAtMicro, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cursor timestamp: %w", err)
+	}
+
+	if _, err := uuid.FromString(id); err != nil {
+		return nil, fmt.Errorf("failed to parse cursor id: %w", err)
+	}
+
+	return &repository.Cursor{
+		CreatedAt: time.UnixMicro(createdAtMicro).UTC(),
+		ID:        id,
+	}, nil
+}
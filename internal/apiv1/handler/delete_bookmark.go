@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/repository"
+)
+
+type DeleteBookmark struct {
+	BookmarkRepository repository.BookmarkRepository
+}
+
+func (h *DeleteBookmark) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	err := h.BookmarkRepository.Delete(ctx, userID, httprouter.RouteParam(ctx, "id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("delete bookmark handler, failed to delete bookmark: %w", err))
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
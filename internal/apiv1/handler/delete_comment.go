@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/postcache"
+	"myfacebook/internal/repository"
+)
+
+type DeleteComment struct {
+	PostRepository    repository.PostRepository
+	CommentRepository repository.CommentRepository
+	PostCache         *postcache.Cache
+}
+
+func (h *DeleteComment) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	commentID := httprouter.RouteParam(ctx, "id")
+
+	comment, err := h.CommentRepository.GetCommentByID(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("delete comment handler, failed to get comment from repo: %w", err))
+	}
+
+	if comment.AuthorID != userID {
+		post, err := h.PostRepository.GetPostByID(ctx, comment.PostID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewServerError(fmt.Errorf("delete comment handler, failed to get post from repo: %w", err))
+		}
+
+		// only the comment author or the post author may delete a comment
+		if post == nil || post.AuthorID != userID {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+	}
+
+	err = h.CommentRepository.Delete(ctx, comment.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("delete comment handler, failed to delete comment: %w", err))
+	}
+
+	err = h.PostCache.DeleteCounters(ctx, comment.PostID)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("delete comment handler, %w", err))
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
@@ -8,12 +8,14 @@ import (
 	"myfacebook/internal/apiv1"
 	"myfacebook/internal/postfeedcache"
 	"myfacebook/internal/repository"
+	"myfacebook/internal/userstats"
 )
 
 type DeleteFriend struct {
-	UserRepository repository.UserRepository
-	PostRepository repository.PostRepository
-	PostFeedCache  *postfeedcache.Cache
+	UserRepository   repository.UserRepository
+	PostRepository   repository.PostRepository
+	PostFeedCache    *postfeedcache.Cache
+	UserStatsCounter *userstats.Counter
 }
 
 func (h *DeleteFriend) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
@@ -23,30 +25,41 @@ func (h *DeleteFriend) Handle(responseWriter http.ResponseWriter, request *http.
 
 	friendID := httprouter.RouteParam(ctx, "id")
 
-	err := h.UserRepository.DeleteFriend(ctx, userID, friendID)
+	deletedCount, err := h.UserRepository.DeleteFriend(ctx, userID, friendID)
 	if err != nil {
 		return apiv1.NewServerError(fmt.Errorf("delete friend handler, failed to delete friend: %w", err))
 	}
 
+	if deletedCount > 0 {
+		err = h.UserStatsCounter.AddFollowers(ctx, friendID, -deletedCount)
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("delete friend handler, failed to uncount follower: %w", err))
+		}
+	}
+
 	postsIDs, err := h.PostFeedCache.GetPostsIDs(ctx, userID)
 	if err != nil {
 		return apiv1.NewServerError(fmt.Errorf("delete friend handler, failed to get posts ids from post feed: %w", err))
 	}
 
 	if len(postsIDs) > 0 {
-		posts, err := h.PostRepository.GetPostsByIDs(ctx, postsIDs, 0, 1000)
+		posts, err := h.PostRepository.GetPostsByIDs(ctx, postsIDs, 0, h.PostFeedCache.MaxFeedLen())
 		if err != nil {
 			return apiv1.NewServerError(fmt.Errorf("delete friend handler, failed to get posts by ids from repo: %w", err))
 		}
 
+		var friendPostsIDs []string
+
 		for _, post := range posts {
 			if post.AuthorID == friendID {
-				err := h.PostFeedCache.RemovePostID(ctx, userID, post.ID)
-				if err != nil {
-					return apiv1.NewServerError(fmt.Errorf("delete friend handler, failed to remove post from post feed: %w", err))
-				}
+				friendPostsIDs = append(friendPostsIDs, post.ID)
 			}
 		}
+
+		err = h.PostFeedCache.RemovePostsIDs(ctx, userID, friendPostsIDs)
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("delete friend handler, failed to remove posts from post feed: %w", err))
+		}
 	}
 
 	responseWriter.Header().Set("Content-Type", "application/json; utf-8")
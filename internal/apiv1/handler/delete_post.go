@@ -1,18 +1,20 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/inbugay1/httprouter"
 	"myfacebook/internal/apiv1"
+	"myfacebook/internal/postcache"
 	"myfacebook/internal/repository"
 	"myfacebook/internal/rmq"
 )
 
 type DeletePost struct {
 	PostRepository repository.PostRepository
+	PostCache      *postcache.Cache
 	RMQ            *rmq.RMQ
 }
 
@@ -26,23 +28,28 @@ func (h *DeletePost) Handle(responseWriter http.ResponseWriter, request *http.Re
 
 	postID := httprouter.RouteParam(ctx, "id")
 
-	err := h.PostRepository.Delete(ctx, postID, authorID)
+	post, err := h.PostRepository.Delete(ctx, postID, authorID)
 	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
 		return apiv1.NewServerError(fmt.Errorf("delete post handler, failed to delete post: %w", err))
 	}
 
-	rmqMessage, err := json.Marshal(postFeedRMQMessage{
-		Operation: "remove",
-		PostID:    postID,
-		AuthorID:  authorID,
-	})
-	if err != nil {
-		return apiv1.NewServerError(fmt.Errorf("delete post handler, failed to make rmq message: %w", err))
+	if post.OriginalPostID != "" {
+		err = h.PostCache.DeleteCounters(ctx, post.OriginalPostID)
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("delete post handler, %w", err))
+		}
 	}
 
-	err = h.RMQ.Publish(ctx, "", "/post/feed", rmqMessage)
+	err = publishPostFeedRMQMessage(ctx, h.RMQ, "remove", repository.Post{
+		ID:       postID,
+		AuthorID: authorID,
+	})
 	if err != nil {
-		return apiv1.NewServerError(fmt.Errorf("delete post handler, failed to publish rmq message: %w", err))
+		return apiv1.NewServerError(fmt.Errorf("delete post handler, %w", err))
 	}
 
 	responseWriter.WriteHeader(http.StatusOK)
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/postreactioncache"
+	"myfacebook/internal/repository"
+)
+
+type DeletePostReaction struct {
+	PostReactionRepository repository.PostReactionRepository
+	PostReactionCache      *postreactioncache.Cache
+}
+
+func (h *DeletePostReaction) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	postID := httprouter.RouteParam(ctx, "id")
+
+	reactionType, err := h.PostReactionRepository.Delete(ctx, postID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("delete post reaction handler, failed to delete post reaction: %w", err))
+	}
+
+	err = h.PostReactionCache.SetUserReaction(ctx, userID, postID, "")
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("delete post reaction handler, %w", err))
+	}
+
+	err = h.PostReactionCache.Incr(ctx, postID, reactionType, -1)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("delete post reaction handler, failed to decrement reaction count: %w", err))
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/postfanoutservice"
+)
+
+type FanoutStats struct {
+	PostFanoutService *postfanoutservice.Service
+}
+
+type fanoutStatsResponse struct {
+	MessagesCount    int64                         `json:"messages_count"`
+	FailuresCount    int64                         `json:"failures_count"`
+	FeedsCount       int64                         `json:"feeds_count"`
+	LatencyAvgMillis float64                       `json:"latency_avg_ms"`
+	LatencyMaxMillis float64                       `json:"latency_max_ms"`
+	LatencyBuckets   []fanoutLatencyBucketResponse `json:"latency_buckets"`
+}
+
+type fanoutLatencyBucketResponse struct {
+	// LeMillis is omitted for the last bucket, which has no upper bound
+	LeMillis float64 `json:"le_ms,omitempty"`
+	Count    int64   `json:"count"`
+}
+
+func (h *FanoutStats) Handle(responseWriter http.ResponseWriter, _ *http.Request) error {
+	metrics := h.PostFanoutService.Metrics()
+
+	fanoutStatsResp := fanoutStatsResponse{
+		MessagesCount:    metrics.MessagesCount,
+		FailuresCount:    metrics.FailuresCount,
+		FeedsCount:       metrics.FeedsCount,
+		LatencyMaxMillis: float64(metrics.LatencyMax.Microseconds()) / 1000,
+		LatencyBuckets:   make([]fanoutLatencyBucketResponse, 0, len(metrics.LatencyBuckets)),
+	}
+
+	if metrics.MessagesCount > 0 {
+		fanoutStatsResp.LatencyAvgMillis = float64(metrics.LatencyTotal.Microseconds()) / 1000 / float64(metrics.MessagesCount)
+	}
+
+	for _, bucket := range metrics.LatencyBuckets {
+		fanoutStatsResp.LatencyBuckets = append(fanoutStatsResp.LatencyBuckets, fanoutLatencyBucketResponse{
+			LeMillis: float64(bucket.UpperBound.Microseconds()) / 1000,
+			Count:    bucket.Count,
+		})
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err := json.NewEncoder(responseWriter).Encode(&fanoutStatsResp)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("fanout stats handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/config"
+	"myfacebook/internal/postfeedcache"
+)
+
+type FeedStats struct {
+	PostFeedCache *postfeedcache.Cache
+	EnvConfig     *config.EnvConfig
+}
+
+type feedStatsResponse struct {
+	FeedsCount    int64 `json:"feeds_count"`
+	PostsCount    int64 `json:"posts_count"`
+	MemoryBytes   int64 `json:"memory_bytes"`
+	Estimated     bool  `json:"estimated"`
+	MaxFeedLength int   `json:"max_feed_length"`
+	FeedTTLHours  int   `json:"feed_ttl_hours"`
+}
+
+func (h *FeedStats) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	stats, err := h.PostFeedCache.Stats(ctx)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("feed stats handler, failed to get feeds stats: %w", err))
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(&feedStatsResponse{
+		FeedsCount:    stats.FeedsCount,
+		PostsCount:    stats.PostsCount,
+		MemoryBytes:   stats.MemoryBytes,
+		Estimated:     stats.Estimated,
+		MaxFeedLength: h.PostFeedCache.MaxFeedLen(),
+		FeedTTLHours:  h.EnvConfig.PostFeedTTLHours,
+	})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("feed stats handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
@@ -5,25 +5,74 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/inbugay1/httprouter"
 	"myfacebook/internal/apiv1"
+	"myfacebook/internal/posttext"
+	"myfacebook/internal/postviewrecorder"
+	"myfacebook/internal/postvisibility"
 	"myfacebook/internal/repository"
 )
 
 type GetPost struct {
-	PostRepository repository.PostRepository
+	PostRepository        repository.PostRepository
+	PostVisibilityChecker *postvisibility.Checker
+	PostHydrator          *PostHydrator
+	PostViewRecorder      *postviewrecorder.Recorder
 }
 
 type getPostResponse struct {
-	ID       string `json:"id"`
-	Text     string `json:"text"`
-	AuthorID string `json:"author_id"`
+	ID             string                `json:"id"`
+	Text           string                `json:"text"`
+	Entities       []posttext.Entity     `json:"entities"`
+	AuthorID       string                `json:"author_id"`
+	Reactions      map[string]int        `json:"reactions"`
+	MyReaction     string                `json:"my_reaction,omitempty"`
+	CommentsCount  int                   `json:"comments_count"`
+	SharesCount    int                   `json:"shares_count"`
+	OriginalPostID string                `json:"original_post_id,omitempty"`
+	OriginalPost   *originalPostResponse `json:"original_post,omitempty"`
+	Poll           *pollResponse         `json:"poll,omitempty"`
+}
+
+// pollResponse carries results only when the user has voted or the poll is closed.
+type pollResponse struct {
+	MultipleChoice bool                 `json:"multiple_choice"`
+	ClosesAt       *time.Time           `json:"closes_at,omitempty"`
+	Closed         bool                 `json:"closed"`
+	Options        []pollOptionResponse `json:"options"`
+	VotersCount    *int                 `json:"voters_count,omitempty"`
+	MyVotes        []int                `json:"my_votes,omitempty"`
+}
+
+type pollOptionResponse struct {
+	ID         int    `json:"id"`
+	Text       string `json:"text"`
+	VotesCount *int   `json:"votes_count,omitempty"`
+}
+
+type originalPostResponse struct {
+	ID          string              `json:"id"`
+	Text        string              `json:"text,omitempty"`
+	Author      *postAuthorResponse `json:"author,omitempty"`
+	Unavailable bool                `json:"unavailable"`
+}
+
+type postAuthorResponse struct {
+	ID         string `json:"id"`
+	FirstName  string `json:"first_name"`
+	SecondName string `json:"second_name"`
 }
 
 func (h *GetPost) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
 	ctx := request.Context()
 
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
 	postID := httprouter.RouteParam(ctx, "id")
 
 	post, err := h.PostRepository.GetPostByID(ctx, postID)
@@ -35,14 +84,26 @@ func (h *GetPost) Handle(responseWriter http.ResponseWriter, request *http.Reque
 		return apiv1.NewServerError(fmt.Errorf("get post handler, failed to get post from repo: %w", err))
 	}
 
+	canView, err := h.PostVisibilityChecker.CanView(ctx, userID, *post)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("get post handler, failed to check post visibility: %w", err))
+	}
+
+	if !canView {
+		return apiv1.NewEntityNotFoundError(nil)
+	}
+
+	postsResponse, err := h.PostHydrator.hydrate(ctx, userID, []repository.Post{*post})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("get post handler, failed to hydrate post: %w", err))
+	}
+
+	h.PostViewRecorder.Record(userID, []repository.Post{*post})
+
 	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
 	responseWriter.WriteHeader(http.StatusOK)
 
-	err = json.NewEncoder(responseWriter).Encode(getPostResponse{
-		ID:       post.ID,
-		Text:     post.Text,
-		AuthorID: post.AuthorID,
-	})
+	err = json.NewEncoder(responseWriter).Encode(postsResponse[0])
 	if err != nil {
 		return apiv1.NewServerError(fmt.Errorf("get post handler, cannot encode response: %w", err))
 	}
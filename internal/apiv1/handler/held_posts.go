@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/repository"
+)
+
+const maxHeldPostsLimit = 100
+
+type HeldPosts struct {
+	PostRepository repository.PostRepository
+	PostHydrator   *PostHydrator
+}
+
+type heldPostsRequest struct {
+	Offset int
+	Limit  int
+}
+
+func (h *HeldPosts) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	heldPostsReq, err := h.getHeldPostsRequest(request)
+	if err != nil {
+		return err
+	}
+
+	posts, err := h.PostRepository.GetHeldPosts(ctx, heldPostsReq.Offset, heldPostsReq.Limit)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("held posts handler, failed to get held posts from repo: %w", err))
+	}
+
+	postsResponse, err := h.PostHydrator.hydrate(ctx, userID, posts)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("held posts handler, failed to hydrate posts: %w", err))
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(postsResponse)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("held posts handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
+
+func (h *HeldPosts) getHeldPostsRequest(request *http.Request) (heldPostsRequest, error) {
+	heldPostsReq := heldPostsRequest{
+		Limit: 10,
+	}
+
+	if request.URL.Query().Get("offset") != "" {
+		offset, err := strconv.Atoi(request.URL.Query().Get("offset"))
+		if err != nil {
+			return heldPostsReq, apiv1.NewInvalidRequestErrorInvalidParameter("offset",
+				fmt.Errorf("held posts handler, failed to convert offset %q to int: %w", request.URL.Query().Get("offset"), err))
+		}
+
+		if offset < 0 {
+			return heldPostsReq, apiv1.NewInvalidRequestErrorInvalidParameter("offset", nil)
+		}
+
+		heldPostsReq.Offset = offset
+	}
+
+	if request.URL.Query().Get("limit") != "" {
+		limit, err := strconv.Atoi(request.URL.Query().Get("limit"))
+		if err != nil {
+			return heldPostsReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit",
+				fmt.Errorf("held posts handler, failed to convert limit %q to int: %w", request.URL.Query().Get("limit"), err))
+		}
+
+		if limit <= 0 || limit > maxHeldPostsLimit {
+			return heldPostsReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit", nil)
+		}
+
+		heldPostsReq.Limit = limit
+	}
+
+	return heldPostsReq, nil
+}
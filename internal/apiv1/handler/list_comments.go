@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/postvisibility"
+	"myfacebook/internal/repository"
+)
+
+const (
+	maxCommentsLimit = 100
+	// a thread is listed down to maxCommentThreadDepth replies deep and with at most maxCommentThreadReplies replies,
+	// the rest of it is read by parent_id
+	maxCommentThreadDepth   = 5
+	maxCommentThreadReplies = 50
+)
+
+type ListComments struct {
+	PostRepository        repository.PostRepository
+	CommentRepository     repository.CommentRepository
+	PostVisibilityChecker *postvisibility.Checker
+}
+
+type listCommentsRequest struct {
+	ParentID string
+	Cursor   *repository.Cursor
+	Limit    int
+}
+
+type commentResponse struct {
+	ID        string             `json:"id"`
+	ParentID  string             `json:"parent_id,omitempty"`
+	AuthorID  string             `json:"author_id,omitempty"`
+	Text      string             `json:"text"`
+	CreatedAt time.Time          `json:"created_at"`
+	Deleted   bool               `json:"deleted"`
+	Replies   []*commentResponse `json:"replies"`
+	// HasMoreReplies tells that the replies to the comment are listed by its id in parent_id from RepliesCursor on.
+	HasMoreReplies bool   `json:"has_more_replies"`
+	RepliesCursor  string `json:"replies_cursor,omitempty"`
+}
+
+type listCommentsResponse struct {
+	Comments   []*commentResponse `json:"comments"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+func (h *ListComments) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	listCommentsReq, err := h.getListCommentsRequest(request)
+	if err != nil {
+		return err
+	}
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	post, err := h.PostRepository.GetPostByID(ctx, httprouter.RouteParam(ctx, "id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("list comments handler, failed to get post from repo: %w", err))
+	}
+
+	canView, err := h.PostVisibilityChecker.CanView(ctx, userID, *post)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("list comments handler, failed to check post visibility: %w", err))
+	}
+
+	if !canView {
+		return apiv1.NewEntityNotFoundError(nil)
+	}
+
+	var listCommentsResp listCommentsResponse
+
+	if listCommentsReq.ParentID != "" {
+		listCommentsResp, err = h.listReplies(ctx, post.ID, listCommentsReq)
+	} else {
+		listCommentsResp, err = h.listThreads(ctx, post.ID, listCommentsReq)
+	}
+
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("list comments handler, %w", err))
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(&listCommentsResp)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("list comments handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
+
+// listThreads lists a page of root comments with their replies, a thread cut by the replies count gets the cursor
+// of the rest of its replies on the root comment.
+func (h *ListComments) listThreads(ctx context.Context, postID string, listCommentsReq listCommentsRequest) (listCommentsResponse, error) {
+	comments, err := h.CommentRepository.GetThreadsByPostID(ctx, postID, listCommentsReq.Cursor, listCommentsReq.Limit,
+		maxCommentThreadDepth, maxCommentThreadReplies)
+	if err != nil {
+		return listCommentsResponse{}, fmt.Errorf("failed to get comments from repo: %w", err)
+	}
+
+	listCommentsResp := listCommentsResponse{
+		Comments: make([]*commentResponse, 0, listCommentsReq.Limit),
+	}
+
+	commentsResp := make(map[string]*commentResponse, len(comments))
+	rootsIDs := make(map[string]string, len(comments))
+	repliesCounts := make(map[string]int)
+	lastReplies := make(map[string]repository.Comment)
+
+	var lastRootComment repository.Comment
+
+	for _, comment := range comments {
+		if comment.ParentID == "" {
+			commentResp := newCommentResponse(comment)
+
+			commentsResp[comment.ID] = commentResp
+			rootsIDs[comment.ID] = comment.ID
+			listCommentsResp.Comments = append(listCommentsResp.Comments, commentResp)
+			lastRootComment = comment
+
+			continue
+		}
+
+		parentResp, ok := commentsResp[comment.ParentID]
+		if !ok {
+			continue
+		}
+
+		rootID := rootsIDs[comment.ParentID]
+
+		// the reply over the limit only tells that the thread goes on after the last listed one
+		if repliesCounts[rootID] == maxCommentThreadReplies {
+			lastReply := lastReplies[rootID]
+
+			rootResp := commentsResp[rootID]
+			rootResp.HasMoreReplies = true
+			rootResp.RepliesCursor = encodeCursor(repository.Cursor{
+				CreatedAt: lastReply.CreatedAt,
+				ID:        lastReply.ID,
+			})
+
+			continue
+		}
+
+		repliesCounts[rootID]++
+		lastReplies[rootID] = comment
+
+		commentResp := newCommentResponse(comment)
+
+		commentsResp[comment.ID] = commentResp
+		rootsIDs[comment.ID] = rootID
+		parentResp.Replies = append(parentResp.Replies, commentResp)
+	}
+
+	if len(listCommentsResp.Comments) == listCommentsReq.Limit {
+		listCommentsResp.NextCursor = encodeCursor(repository.Cursor{
+			CreatedAt: lastRootComment.CreatedAt,
+			ID:        lastRootComment.ID,
+		})
+	}
+
+	return listCommentsResp, nil
+}
+
+// listReplies lists a page of the replies to the comment. A reply whose parent is on an earlier page
+// is listed at the top level.
+func (h *ListComments) listReplies(ctx context.Context, postID string, listCommentsReq listCommentsRequest) (listCommentsResponse, error) {
+	comments, err := h.CommentRepository.GetRepliesByCommentID(ctx, postID, listCommentsReq.ParentID, listCommentsReq.Cursor,
+		listCommentsReq.Limit, maxCommentThreadDepth)
+	if err != nil {
+		return listCommentsResponse{}, fmt.Errorf("failed to get comment replies from repo: %w", err)
+	}
+
+	listCommentsResp := listCommentsResponse{
+		Comments: make([]*commentResponse, 0, len(comments)),
+	}
+
+	commentsResp := make(map[string]*commentResponse, len(comments))
+
+	for _, comment := range comments {
+		commentResp := newCommentResponse(comment)
+
+		commentsResp[comment.ID] = commentResp
+
+		if parentResp, ok := commentsResp[comment.ParentID]; ok {
+			parentResp.Replies = append(parentResp.Replies, commentResp)
+
+			continue
+		}
+
+		listCommentsResp.Comments = append(listCommentsResp.Comments, commentResp)
+	}
+
+	if len(comments) == listCommentsReq.Limit {
+		lastComment := comments[len(comments)-1]
+
+		listCommentsResp.NextCursor = encodeCursor(repository.Cursor{
+			CreatedAt: lastComment.CreatedAt,
+			ID:        lastComment.ID,
+		})
+	}
+
+	return listCommentsResp, nil
+}
+
+func newCommentResponse(comment repository.Comment) *commentResponse {
+	commentResp := &commentResponse{
+		ID:             comment.ID,
+		ParentID:       comment.ParentID,
+		AuthorID:       comment.AuthorID,
+		Text:           comment.Text,
+		CreatedAt:      comment.CreatedAt,
+		Deleted:        comment.Deleted,
+		Replies:        []*commentResponse{},
+		HasMoreReplies: comment.HasHiddenReplies,
+	}
+
+	if comment.Deleted {
+		commentResp.AuthorID = ""
+		commentResp.Text = ""
+	}
+
+	return commentResp
+}
+
+func (h *ListComments) getListCommentsRequest(request *http.Request) (listCommentsRequest, error) {
+	listCommentsReq := listCommentsRequest{
+		ParentID: request.URL.Query().Get("parent_id"),
+		Limit:    20,
+	}
+
+	if listCommentsReq.ParentID != "" {
+		if _, err := uuid.FromString(listCommentsReq.ParentID); err != nil {
+			return listCommentsReq, apiv1.NewInvalidRequestErrorInvalidParameter("parent_id", err)
+		}
+	}
+
+	if cursor := request.URL.Query().Get("cursor"); cursor != "" {
+		decodedCursor, err := decodeCursor(cursor)
+		if err != nil {
+			return listCommentsReq, apiv1.NewInvalidRequestErrorInvalidParameter("cursor",
+				fmt.Errorf("list comments handler, failed to decode cursor %q: %w", cursor, err))
+		}
+
+		listCommentsReq.Cursor = decodedCursor
+	}
+
+	if request.URL.Query().Get("limit") != "" {
+		limit, err := strconv.Atoi(request.URL.Query().Get("limit"))
+		if err != nil {
+			return listCommentsReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit",
+				fmt.Errorf("list comments handler, failed to convert limit %q to int: %w", request.URL.Query().Get("limit"), err))
+		}
+
+		if limit <= 0 || limit > maxCommentsLimit {
+			return listCommentsReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit", nil)
+		}
+
+		listCommentsReq.Limit = limit
+	}
+
+	return listCommentsReq, nil
+}
@@ -52,6 +52,10 @@ func (h *Login) Handle(responseWriter http.ResponseWriter, request *http.Request
 		return apiv1.NewInvalidCredentialsError()
 	}
 
+	if user.Banned {
+		return apiv1.NewUserBannedError()
+	}
+
 	tokenUUIDv4, err := uuid.NewV4()
 	if err != nil {
 		return apiv1.NewServerError(fmt.Errorf("login handler, failed to generate token uuid: %w", err))
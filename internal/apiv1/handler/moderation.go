@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/moderation"
+)
+
+// moderate runs the content through the moderation pipeline. Rejected content is returned as an error,
+// otherwise the verdict tells whether the content may be published or has to wait for review.
+func moderate(ctx context.Context, pipeline *moderation.Pipeline, content moderation.Content) (moderation.Verdict, error) {
+	result, err := pipeline.Run(ctx, content)
+	if err != nil {
+		return moderation.VerdictAllow, apiv1.NewServerError(fmt.Errorf("failed to moderate %s: %w", content.Kind, err))
+	}
+
+	if result.Verdict == moderation.VerdictReject {
+		return result.Verdict, apiv1.NewContentRejectedError(result.Reason)
+	}
+
+	return result.Verdict, nil
+}
+
+// moderateWithoutReview is for the content that has no review queue, so content held for review is rejected.
+func moderateWithoutReview(ctx context.Context, pipeline *moderation.Pipeline, content moderation.Content) error {
+	verdict, err := moderate(ctx, pipeline, content)
+	if err != nil {
+		return err
+	}
+
+	if verdict == moderation.VerdictHold {
+		if err := pipeline.Release(ctx, content); err != nil {
+			return apiv1.NewServerError(fmt.Errorf("failed to release moderated %s: %w", content.Kind, err))
+		}
+
+		return apiv1.NewContentRejectedError("needs review")
+	}
+
+	return nil
+}
+
+// releaseModerated gives back what the moderation has reserved for the content that failed to be stored,
+// err is the store error.
+func releaseModerated(ctx context.Context, pipeline *moderation.Pipeline, content moderation.Content, err error) error {
+	if releaseErr := pipeline.Release(ctx, content); releaseErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to release moderated %s: %w", content.Kind, releaseErr))
+	}
+
+	return apiv1.NewServerError(err)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/repository"
+)
+
+// MuteFriend hides the friend posts from the user feed without unfriending. The posts already in the feed
+// are filtered out on read, the new ones are not pushed to the feed at all.
+type MuteFriend struct {
+	UserRepository repository.UserRepository
+}
+
+func (h *MuteFriend) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	friendID := httprouter.RouteParam(ctx, "id")
+
+	err := h.UserRepository.MuteFriend(ctx, userID, friendID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("mute friend handler, failed to mute friend: %w", err))
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
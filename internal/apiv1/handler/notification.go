@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"myfacebook/internal/rmq"
+)
+
+const notificationsExchange = "/user/notifications"
+
+type notificationRMQMessage struct {
+	Type      string `json:"type"`
+	ActorID   string `json:"actor_id"`
+	PostID    string `json:"post_id,omitempty"`
+	CommentID string `json:"comment_id,omitempty"`
+	Reaction  string `json:"reaction,omitempty"`
+}
+
+func publishNotification(ctx context.Context, rabbitMQ *rmq.RMQ, recipientID string, notification notificationRMQMessage) error {
+	if recipientID == notification.ActorID {
+		return nil
+	}
+
+	notificationMsg, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to make notification rmq message: %w", err)
+	}
+
+	err = rabbitMQ.Publish(ctx, notificationsExchange, recipientID, notificationMsg)
+	if err != nil {
+		return fmt.Errorf("failed to publish notification rmq message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/repository"
+)
+
+type PostDrafts struct {
+	PostDraftRepository repository.PostDraftRepository
+}
+
+type postDraftResponse struct {
+	ID         string    `json:"id"`
+	Text       string    `json:"text"`
+	Visibility string    `json:"visibility,omitempty"`
+	Audience   []string  `json:"audience,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func (h *PostDrafts) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	authorID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	drafts, err := h.PostDraftRepository.GetDraftsByAuthorID(ctx, authorID)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("post drafts handler, failed to get post drafts from repo: %w", err))
+	}
+
+	postDraftsResponse := make([]postDraftResponse, 0, len(drafts))
+
+	for _, draft := range drafts {
+		postDraftsResponse = append(postDraftsResponse, postDraftResponse{
+			ID:         draft.ID,
+			Text:       draft.Text,
+			Visibility: draft.Visibility,
+			Audience:   draft.Audience,
+			UpdatedAt:  draft.UpdatedAt,
+			ExpiresAt:  draft.ExpiresAt,
+		})
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(postDraftsResponse)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("post drafts handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"myfacebook/internal/posttext"
+	"myfacebook/internal/repository"
+	"myfacebook/internal/rmq"
+)
+
+// savePostEntities stores hashtags and mentions of the post text and notifies newly mentioned users.
+func savePostEntities(ctx context.Context, postRepository repository.PostRepository, userRepository repository.UserRepository,
+	rabbitMQ *rmq.RMQ, actorID string, post repository.Post,
+) error {
+	entities := posttext.ParseEntities(post.Text)
+
+	err := postRepository.SetTags(ctx, post.ID, posttext.Hashtags(entities))
+	if err != nil {
+		return fmt.Errorf("failed to set post tags: %w", err)
+	}
+
+	var mentionedUsersIDs []string
+
+	if mentions := posttext.Mentions(entities); len(mentions) > 0 {
+		mentionedUsers, err := userRepository.GetUsersByIDs(ctx, mentions)
+		if err != nil {
+			return fmt.Errorf("failed to get mentioned users: %w", err)
+		}
+
+		for _, mentionedUser := range mentionedUsers {
+			mentionedUsersIDs = append(mentionedUsersIDs, mentionedUser.ID)
+		}
+	}
+
+	newMentionedUsersIDs, err := postRepository.SetMentions(ctx, post.ID, mentionedUsersIDs)
+	if err != nil {
+		return fmt.Errorf("failed to set post mentions: %w", err)
+	}
+
+	// mentioned users cannot see a scheduled or held post yet
+	if post.Status != repository.PostStatusPublished && post.Status != "" {
+		return nil
+	}
+
+	for _, mentionedUserID := range newMentionedUsersIDs {
+		err := publishNotification(ctx, rabbitMQ, mentionedUserID, notificationRMQMessage{
+			Type:    "post_mention",
+			ActorID: actorID,
+			PostID:  post.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,28 +1,114 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
+	"github.com/gofrs/uuid"
 	"myfacebook/internal/apiv1"
 	"myfacebook/internal/config"
+	"myfacebook/internal/postcache"
+	"myfacebook/internal/postfeedbuilder"
 	"myfacebook/internal/postfeedcache"
+	"myfacebook/internal/postranker"
+	"myfacebook/internal/postviewrecorder"
+	"myfacebook/internal/postvisibility"
 	"myfacebook/internal/repository"
 )
 
+const (
+	maxPostFeedLimit = 100
+	// the number of posts ids read from the cached feed at once while looking for the posts matching the filter
+	postFeedScanCount = 50
+)
+
+const (
+	postFeedModeChronological = "chronological"
+	postFeedModeTop           = "top"
+)
+
 type PostFeed struct {
-	PostRepository repository.PostRepository
-	UserRepository repository.UserRepository
-	PostFeedCache  *postfeedcache.Cache
-	EnvConfig      *config.EnvConfig
+	PostRepository        repository.PostRepository
+	UserRepository        repository.UserRepository
+	PostFeedCache         *postfeedcache.Cache
+	PostCache             *postcache.Cache
+	PostFeedBuilder       *postfeedbuilder.Builder
+	PostVisibilityChecker *postvisibility.Checker
+	PostHydrator          *PostHydrator
+	PostViewRecorder      *postviewrecorder.Recorder
+	PostRankerSelector    *postranker.Selector
+	EnvConfig             *config.EnvConfig
 }
 
+// postFeedRequest is paged by the cursor when the cursor parameter is given, even empty for the first page,
+// and by the offset otherwise. The top mode is paged by the offset only.
 type postFeedRequest struct {
+	Mode   string
+	Paged  bool
+	Cursor *repository.Cursor
 	Offset int
 	Limit  int
+
+	AuthorsIDs []string
+	Since      time.Time
+	Until      time.Time
+}
+
+// postFeedFilter drops the posts of the muted friends and the posts not matching the request filters.
+type postFeedFilter struct {
+	mutedAuthorsIDs map[string]struct{}
+	authorsIDs      map[string]struct{}
+	since           time.Time
+	until           time.Time
+}
+
+func newPostFeedFilter(postFeedReq postFeedRequest, mutedAuthorsIDs []string) *postFeedFilter {
+	filter := &postFeedFilter{
+		mutedAuthorsIDs: make(map[string]struct{}, len(mutedAuthorsIDs)),
+		since:           postFeedReq.Since,
+		until:           postFeedReq.Until,
+	}
+
+	for _, authorID := range mutedAuthorsIDs {
+		filter.mutedAuthorsIDs[authorID] = struct{}{}
+	}
+
+	if len(postFeedReq.AuthorsIDs) > 0 {
+		filter.authorsIDs = make(map[string]struct{}, len(postFeedReq.AuthorsIDs))
+
+		for _, authorID := range postFeedReq.AuthorsIDs {
+			filter.authorsIDs[authorID] = struct{}{}
+		}
+	}
+
+	return filter
+}
+
+func (f *postFeedFilter) match(post repository.Post) bool {
+	if _, ok := f.mutedAuthorsIDs[post.AuthorID]; ok {
+		return false
+	}
+
+	if f.authorsIDs != nil {
+		if _, ok := f.authorsIDs[post.AuthorID]; !ok {
+			return false
+		}
+	}
+
+	if f.isBeforeSince(post) {
+		return false
+	}
+
+	return f.until.IsZero() || !post.CreatedAt.After(f.until)
+}
+
+func (f *postFeedFilter) isBeforeSince(post repository.Post) bool {
+	return !f.since.IsZero() && post.CreatedAt.Before(f.since)
 }
 
 func (h *PostFeed) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
@@ -38,13 +124,25 @@ func (h *PostFeed) Handle(responseWriter http.ResponseWriter, request *http.Requ
 		return err
 	}
 
+	mutedFriendsIDs, err := h.UserRepository.GetMutedFriendsIDs(ctx, userID)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("post feed handler, failed to get muted friends ids: %w", err))
+	}
+
+	filter := newPostFeedFilter(postFeedReq, mutedFriendsIDs)
+
+	err = h.PostFeedBuilder.EnsureFeed(ctx, userID)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("post feed handler, failed to ensure feed: %w", err))
+	}
+
 	lastRetrievedAtTimestamp, err := h.PostFeedCache.GetLastRetrievedAt(ctx, userID)
 	if err != nil {
 		return apiv1.NewServerError(fmt.Errorf("post feed handler, failed to get last retrieved timestamp: %w", err))
 	}
 
 	if time.Since(time.UnixMilli(lastRetrievedAtTimestamp)) > time.Duration(h.EnvConfig.PopularFriendPostsRetrieveIntervalMinutes)*time.Minute {
-		popularFriendsIDs, err := h.UserRepository.GetPopularFriendsIDsByUserID(ctx, userID, h.EnvConfig.PopularFriendUsersCount)
+		popularFriendsIDs, err := h.UserRepository.GetCelebrityFriendsIDsByUserID(ctx, userID)
 		if err != nil {
 			return apiv1.NewServerError(fmt.Errorf("post feed handler, failed to get popular friends ids: %w", err))
 		}
@@ -55,8 +153,22 @@ func (h *PostFeed) Handle(responseWriter http.ResponseWriter, request *http.Requ
 				return apiv1.NewServerError(fmt.Errorf("post feed handler, failed to get last posts ids by author ids from post repo: %w", err))
 			}
 
-			for _, postID := range popularFriendsPostsIDs {
-				err = h.PostFeedCache.AddPostID(ctx, userID, postID)
+			var popularFriendsPosts []repository.Post
+
+			if len(popularFriendsPostsIDs) > 0 {
+				popularFriendsPosts, err = h.PostRepository.GetPostsByIDs(ctx, popularFriendsPostsIDs, 0, len(popularFriendsPostsIDs))
+				if err != nil {
+					return apiv1.NewServerError(fmt.Errorf("post feed handler, failed to get popular friends posts from post repo: %w", err))
+				}
+			}
+
+			visiblePosts, err := h.PostVisibilityChecker.FilterVisible(ctx, userID, popularFriendsPosts)
+			if err != nil {
+				return apiv1.NewServerError(fmt.Errorf("post feed handler, failed to filter visible popular friends posts: %w", err))
+			}
+
+			for _, post := range visiblePosts {
+				err = h.PostFeedCache.AddPostID(ctx, userID, post.ID, post.CreatedAt)
 				if err != nil {
 					return apiv1.NewServerError(fmt.Errorf("post feed handler, failed to add post id to post feed cache: %w", err))
 				}
@@ -69,32 +181,51 @@ func (h *PostFeed) Handle(responseWriter http.ResponseWriter, request *http.Requ
 		}
 	}
 
-	cachedPostsIDs, err := h.PostFeedCache.GetPostsIDs(ctx, userID)
+	// feeds of the users not reading them expire
+	err = h.PostFeedCache.Touch(ctx, userID)
 	if err != nil {
-		return apiv1.NewServerError(fmt.Errorf("post feed handler, failed to fetch posts ids from cache: %w", err))
+		return apiv1.NewServerError(fmt.Errorf("post feed handler, failed to touch feed: %w", err))
 	}
 
-	postFeedResponse := make([]getPostResponse, 0, postFeedReq.Limit)
+	var (
+		posts      []repository.Post
+		nextCursor string
+		rankerName = postFeedModeChronological
+	)
 
-	if len(cachedPostsIDs) > 0 {
-		posts, err := h.PostRepository.GetPostsByIDs(ctx, cachedPostsIDs, postFeedReq.Offset, postFeedReq.Limit)
-		if err != nil {
-			return apiv1.NewServerError(fmt.Errorf("post feed handler, failed to get posts by ids from repo: %w", err))
-		}
+	switch {
+	case postFeedReq.Mode == postFeedModeTop:
+		posts, rankerName, err = h.getTopPosts(ctx, userID, postFeedReq, filter)
+	case postFeedReq.Paged:
+		posts, nextCursor, err = h.getPostsPage(ctx, userID, postFeedReq, filter)
+	default:
+		posts, err = h.getPostsByOffset(ctx, userID, postFeedReq, filter)
+	}
 
-		for _, post := range posts {
-			postFeedResponse = append(postFeedResponse, getPostResponse{
-				ID:       post.ID,
-				Text:     post.Text,
-				AuthorID: post.AuthorID,
-			})
-		}
+	if err != nil {
+		return err
+	}
+
+	postFeedResponse, err := h.PostHydrator.hydrate(ctx, userID, posts)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("post feed handler, failed to hydrate posts: %w", err))
 	}
 
+	h.PostViewRecorder.Record(userID, posts)
+
 	responseWriter.Header().Set("Content-Type", "application/json; utf-8")
+	responseWriter.Header().Set("X-Feed-Ranker", rankerName)
 	responseWriter.WriteHeader(http.StatusOK)
 
-	err = json.NewEncoder(responseWriter).Encode(&postFeedResponse)
+	if postFeedReq.Paged {
+		err = json.NewEncoder(responseWriter).Encode(&postsPageResponse{
+			Posts:      postFeedResponse,
+			NextCursor: nextCursor,
+		})
+	} else {
+		err = json.NewEncoder(responseWriter).Encode(&postFeedResponse)
+	}
+
 	if err != nil {
 		return apiv1.NewServerError(fmt.Errorf("post feed handler, cannot encode response: %w", err))
 	}
@@ -102,9 +233,291 @@ func (h *PostFeed) Handle(responseWriter http.ResponseWriter, request *http.Requ
 	return nil
 }
 
+// getPostsPage returns the posts following the cursor.
+func (h *PostFeed) getPostsPage(ctx context.Context, userID string, postFeedReq postFeedRequest,
+	filter *postFeedFilter,
+) ([]repository.Post, string, error) {
+	posts, hasMore, err := h.scanPosts(ctx, userID, postFeedReq.Cursor, filter, 0, postFeedReq.Limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+
+	if hasMore && len(posts) > 0 {
+		lastPost := posts[len(posts)-1]
+
+		nextCursor = encodeCursor(repository.Cursor{
+			CreatedAt: lastPost.CreatedAt,
+			ID:        lastPost.ID,
+		})
+	}
+
+	return posts, nextCursor, nil
+}
+
+func (h *PostFeed) getPostsByOffset(ctx context.Context, userID string, postFeedReq postFeedRequest,
+	filter *postFeedFilter,
+) ([]repository.Post, error) {
+	if postFeedReq.Limit <= 0 {
+		return nil, nil
+	}
+
+	posts, _, err := h.scanPosts(ctx, userID, nil, filter, postFeedReq.Offset, postFeedReq.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// scanPosts walks the cached feed following the cursor post in chunks, skips the first skip posts visible to the user
+// and matching the filter, and collects up to limit of the next ones. hasMore is false when the feed is known to end
+// before the collected posts run out.
+func (h *PostFeed) scanPosts(ctx context.Context, userID string, cursor *repository.Cursor, filter *postFeedFilter,
+	skip, limit int,
+) (posts []repository.Post, hasMore bool, err error) {
+	var (
+		afterPostID    string
+		afterCreatedAt time.Time
+	)
+
+	if cursor != nil {
+		afterPostID = cursor.ID
+		afterCreatedAt = cursor.CreatedAt
+	}
+
+	count := max(skip+limit, postFeedScanCount)
+
+	// the feed is walked once at most, a post removed from the feed while it is walked may bring some posts again
+	for scannedCount := 0; scannedCount < h.PostFeedCache.MaxFeedLen(); {
+		cachedPostsIDs, err := h.PostFeedCache.GetPostsIDsAfter(ctx, userID, afterPostID, afterCreatedAt, count)
+		if err != nil {
+			return nil, false, apiv1.NewServerError(fmt.Errorf("post feed handler, failed to fetch posts ids from cache: %w", err))
+		}
+
+		if len(cachedPostsIDs) == 0 {
+			return posts, false, nil
+		}
+
+		scannedCount += len(cachedPostsIDs)
+
+		chunkPosts, err := h.getPostsByIDs(ctx, cachedPostsIDs)
+		if err != nil {
+			return nil, false, err
+		}
+
+		// posts of the same millisecond as the cursor one come again when it is not in the feed anymore,
+		// the feed orders them by id
+		if cursor != nil {
+			cursorMilli := cursor.CreatedAt.UnixMilli()
+
+			chunkPosts = slices.DeleteFunc(chunkPosts, func(post repository.Post) bool {
+				postMilli := post.CreatedAt.UnixMilli()
+
+				return postMilli > cursorMilli || (postMilli == cursorMilli && post.ID >= cursor.ID)
+			})
+
+			cursor = nil
+		}
+
+		afterPostID = cachedPostsIDs[len(cachedPostsIDs)-1]
+
+		// the feed is ordered by time, so nothing newer than since follows a post older than it
+		feedEnded := len(cachedPostsIDs) < count
+
+		if len(chunkPosts) > 0 {
+			afterCreatedAt = chunkPosts[len(chunkPosts)-1].CreatedAt
+			feedEnded = feedEnded || filter.isBeforeSince(chunkPosts[len(chunkPosts)-1])
+		}
+
+		chunkPosts = slices.DeleteFunc(chunkPosts, func(post repository.Post) bool {
+			return !filter.match(post)
+		})
+
+		chunkPosts, err = h.PostVisibilityChecker.FilterVisible(ctx, userID, chunkPosts)
+		if err != nil {
+			return nil, false, apiv1.NewServerError(fmt.Errorf("post feed handler, failed to filter visible posts: %w", err))
+		}
+
+		for _, post := range chunkPosts {
+			if skip > 0 {
+				skip--
+
+				continue
+			}
+
+			posts = append(posts, post)
+
+			if len(posts) == limit {
+				return posts, true, nil
+			}
+		}
+
+		if feedEnded {
+			return posts, false, nil
+		}
+	}
+
+	return posts, false, nil
+}
+
+// getTopPosts ranks the last posts of the cached feed and returns the requested page of them along with the ranker name.
+func (h *PostFeed) getTopPosts(ctx context.Context, userID string, postFeedReq postFeedRequest,
+	filter *postFeedFilter,
+) ([]repository.Post, string, error) {
+	ranker := h.PostRankerSelector.Select(userID)
+
+	if postFeedReq.Limit <= 0 || postFeedReq.Offset >= h.EnvConfig.FeedTopCandidates {
+		return nil, ranker.Name(), nil
+	}
+
+	cachedPostsIDs, err := h.PostFeedCache.GetPostsIDsRange(ctx, userID, 0, h.EnvConfig.FeedTopCandidates)
+	if err != nil {
+		return nil, "", apiv1.NewServerError(fmt.Errorf("post feed handler, failed to fetch posts ids from cache: %w", err))
+	}
+
+	if len(cachedPostsIDs) == 0 {
+		return nil, ranker.Name(), nil
+	}
+
+	posts, err := h.getPostsByIDs(ctx, cachedPostsIDs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	posts = slices.DeleteFunc(posts, func(post repository.Post) bool {
+		return !filter.match(post)
+	})
+
+	posts, err = h.PostVisibilityChecker.FilterVisible(ctx, userID, posts)
+	if err != nil {
+		return nil, "", apiv1.NewServerError(fmt.Errorf("post feed handler, failed to filter visible posts: %w", err))
+	}
+
+	posts, err = ranker.Rank(ctx, userID, posts)
+	if err != nil {
+		return nil, "", apiv1.NewServerError(fmt.Errorf("post feed handler, failed to rank posts: %w", err))
+	}
+
+	if postFeedReq.Offset >= len(posts) {
+		return nil, ranker.Name(), nil
+	}
+
+	return posts[postFeedReq.Offset:min(postFeedReq.Offset+postFeedReq.Limit, len(posts))], ranker.Name(), nil
+}
+
+// getPostsByIDs takes the posts from the post cache in the feed order, only the missed ones are read from the db
+// and put into the cache.
+func (h *PostFeed) getPostsByIDs(ctx context.Context, postIDs []string) ([]repository.Post, error) {
+	cachedPosts, missedPostsIDs, err := h.PostCache.GetPosts(ctx, postIDs)
+	if err != nil {
+		return nil, apiv1.NewServerError(fmt.Errorf("post feed handler, failed to get posts from cache: %w", err))
+	}
+
+	if len(missedPostsIDs) > 0 {
+		missedPosts, err := h.PostRepository.GetPostsByIDs(ctx, missedPostsIDs, 0, len(missedPostsIDs))
+		if err != nil {
+			return nil, apiv1.NewServerError(fmt.Errorf("post feed handler, failed to get posts by ids from repo: %w", err))
+		}
+
+		var publishedPosts []repository.Post
+
+		for _, post := range missedPosts {
+			cachedPosts[post.ID] = post
+
+			if post.Status == repository.PostStatusPublished {
+				publishedPosts = append(publishedPosts, post)
+			}
+		}
+
+		if len(publishedPosts) > 0 {
+			err = h.PostCache.SetPosts(ctx, publishedPosts)
+			if err != nil {
+				return nil, apiv1.NewServerError(fmt.Errorf("post feed handler, failed to cache posts: %w", err))
+			}
+		}
+	}
+
+	posts := make([]repository.Post, 0, len(postIDs))
+
+	for _, postID := range postIDs {
+		if post, ok := cachedPosts[postID]; ok {
+			posts = append(posts, post)
+		}
+	}
+
+	return posts, nil
+}
+
 func (h *PostFeed) getPostFeedRequest(request *http.Request) (postFeedRequest, error) {
+	postFeedReq, err := h.getPostFeedModeRequest(request)
+	if err != nil {
+		return postFeedReq, err
+	}
+
+	for _, authorID := range request.URL.Query()["author_id"] {
+		if _, err := uuid.FromString(authorID); err != nil {
+			return postFeedReq, apiv1.NewInvalidRequestErrorInvalidParameter("author_id",
+				fmt.Errorf("post feed handler, invalid author id %q: %w", authorID, err))
+		}
+
+		postFeedReq.AuthorsIDs = append(postFeedReq.AuthorsIDs, authorID)
+	}
+
+	postFeedReq.Since, err = getPostFeedTime(request, "since")
+	if err != nil {
+		return postFeedReq, err
+	}
+
+	postFeedReq.Until, err = getPostFeedTime(request, "until")
+	if err != nil {
+		return postFeedReq, err
+	}
+
+	if !postFeedReq.Since.IsZero() && !postFeedReq.Until.IsZero() && postFeedReq.Since.After(postFeedReq.Until) {
+		return postFeedReq, apiv1.NewInvalidRequestErrorInvalidParameter("until", nil)
+	}
+
+	return postFeedReq, nil
+}
+
+// getPostFeedTime returns the zero time when the parameter is not given.
+func getPostFeedTime(request *http.Request, param string) (time.Time, error) {
+	value := request.URL.Query().Get(param)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	parsedTime, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, apiv1.NewInvalidRequestErrorInvalidParameter(param,
+			fmt.Errorf("post feed handler, failed to parse %s %q: %w", param, value, err))
+	}
+
+	return parsedTime, nil
+}
+
+func (h *PostFeed) getPostFeedModeRequest(request *http.Request) (postFeedRequest, error) {
 	var postFeedReq postFeedRequest
 
+	switch request.URL.Query().Get("mode") {
+	case "", postFeedModeChronological:
+		postFeedReq.Mode = postFeedModeChronological
+	case postFeedModeTop:
+		postFeedReq.Mode = postFeedModeTop
+
+		if request.URL.Query().Has("cursor") {
+			return postFeedReq, apiv1.NewInvalidRequestErrorInvalidParameter("cursor", nil)
+		}
+	default:
+		return postFeedReq, apiv1.NewInvalidRequestErrorInvalidParameter("mode", nil)
+	}
+
+	if request.URL.Query().Has("cursor") {
+		return h.getPostFeedPageRequest(request)
+	}
+
 	if request.URL.Query().Get("offset") == "" {
 		postFeedReq.Offset = 0
 	} else {
@@ -114,6 +527,10 @@ func (h *PostFeed) getPostFeedRequest(request *http.Request) (postFeedRequest, e
 				fmt.Errorf("post feed handler, failed to convert offset %q to int: %w", request.URL.Query().Get("offset"), err))
 		}
 
+		if offset < 0 {
+			return postFeedReq, apiv1.NewInvalidRequestErrorInvalidParameter("offset", nil)
+		}
+
 		postFeedReq.Offset = offset
 	}
 
@@ -131,3 +548,37 @@ func (h *PostFeed) getPostFeedRequest(request *http.Request) (postFeedRequest, e
 
 	return postFeedReq, nil
 }
+
+func (h *PostFeed) getPostFeedPageRequest(request *http.Request) (postFeedRequest, error) {
+	postFeedReq := postFeedRequest{
+		Mode:  postFeedModeChronological,
+		Paged: true,
+		Limit: 10,
+	}
+
+	if cursor := request.URL.Query().Get("cursor"); cursor != "" {
+		decodedCursor, err := decodeCursor(cursor)
+		if err != nil {
+			return postFeedReq, apiv1.NewInvalidRequestErrorInvalidParameter("cursor",
+				fmt.Errorf("post feed handler, failed to decode cursor %q: %w", cursor, err))
+		}
+
+		postFeedReq.Cursor = decodedCursor
+	}
+
+	if request.URL.Query().Get("limit") != "" {
+		limit, err := strconv.Atoi(request.URL.Query().Get("limit"))
+		if err != nil {
+			return postFeedReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit",
+				fmt.Errorf("post feed handler, failed to convert limit %q to int: %w", request.URL.Query().Get("limit"), err))
+		}
+
+		if limit <= 0 || limit > maxPostFeedLimit {
+			return postFeedReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit", nil)
+		}
+
+		postFeedReq.Limit = limit
+	}
+
+	return postFeedReq, nil
+}
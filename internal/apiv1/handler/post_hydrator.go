@@ -0,0 +1,384 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"myfacebook/internal/postcache"
+	"myfacebook/internal/postpollcache"
+	"myfacebook/internal/postreactioncache"
+	"myfacebook/internal/posttext"
+	"myfacebook/internal/postvisibility"
+	"myfacebook/internal/repository"
+)
+
+type PostHydrator struct {
+	PostRepository         repository.PostRepository
+	PostVisibilityChecker  *postvisibility.Checker
+	UserRepository         repository.UserRepository
+	PostReactionRepository repository.PostReactionRepository
+	CommentRepository      repository.CommentRepository
+	PollRepository         repository.PollRepository
+	PostReactionCache      *postreactioncache.Cache
+	PostPollCache          *postpollcache.Cache
+	PostCache              *postcache.Cache
+}
+
+func (h *PostHydrator) hydrate(ctx context.Context, userID string, posts []repository.Post) ([]getPostResponse, error) {
+	postsResponse := make([]getPostResponse, 0, len(posts))
+
+	if len(posts) == 0 {
+		return postsResponse, nil
+	}
+
+	postsIDs := make([]string, 0, len(posts))
+	for _, post := range posts {
+		postsIDs = append(postsIDs, post.ID)
+	}
+
+	reactionsCounts, err := h.getReactionsCounts(ctx, postsIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	userReactions, err := h.getUserReactions(ctx, userID, postsIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	counters, err := h.getCounters(ctx, postsIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	originalPosts, err := h.getOriginalPosts(ctx, userID, posts)
+	if err != nil {
+		return nil, err
+	}
+
+	polls, err := h.getPolls(ctx, userID, postsIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, post := range posts {
+		postReactions := reactionsCounts[post.ID]
+		if postReactions == nil {
+			postReactions = map[string]int{}
+		}
+
+		entities := posttext.ParseEntities(post.Text)
+		if entities == nil {
+			entities = []posttext.Entity{}
+		}
+
+		postsResponse = append(postsResponse, getPostResponse{
+			ID:             post.ID,
+			Text:           post.Text,
+			Entities:       entities,
+			AuthorID:       post.AuthorID,
+			Reactions:      postReactions,
+			MyReaction:     userReactions[post.ID],
+			CommentsCount:  counters[post.ID].Comments,
+			SharesCount:    counters[post.ID].Shares,
+			OriginalPostID: post.OriginalPostID,
+			OriginalPost:   originalPosts[post.OriginalPostID],
+			Poll:           polls[post.ID],
+		})
+	}
+
+	return postsResponse, nil
+}
+
+func (h *PostHydrator) getReactionsCounts(ctx context.Context, postsIDs []string) (map[string]map[string]int, error) {
+	counts, missedPostsIDs, err := h.PostReactionCache.GetCounts(ctx, postsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reactions counts from cache: %w", err)
+	}
+
+	if len(missedPostsIDs) == 0 {
+		return counts, nil
+	}
+
+	missedCounts, err := h.PostReactionRepository.GetCountsByPostIDs(ctx, missedPostsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reactions counts from repo: %w", err)
+	}
+
+	for postID, postCounts := range missedCounts {
+		counts[postID] = postCounts
+
+		err := h.PostReactionCache.SetCounts(ctx, postID, postCounts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set reactions counts to cache: %w", err)
+		}
+	}
+
+	return counts, nil
+}
+
+func (h *PostHydrator) getUserReactions(ctx context.Context, userID string, postsIDs []string) (map[string]string, error) {
+	reactions, missedPostsIDs, err := h.PostReactionCache.GetUserReactions(ctx, userID, postsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user reactions from cache: %w", err)
+	}
+
+	if len(missedPostsIDs) == 0 {
+		return reactions, nil
+	}
+
+	missedReactions, err := h.PostReactionRepository.GetUserReactionsByPostIDs(ctx, userID, missedPostsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user reactions from repo: %w", err)
+	}
+
+	for postID, reactionType := range missedReactions {
+		reactions[postID] = reactionType
+	}
+
+	err = h.PostReactionCache.FillUserReactions(ctx, userID, missedPostsIDs, missedReactions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set user reactions to cache: %w", err)
+	}
+
+	return reactions, nil
+}
+
+func (h *PostHydrator) getCounters(ctx context.Context, postsIDs []string) (map[string]postcache.Counters, error) {
+	counters, missedPostsIDs, err := h.PostCache.GetCounters(ctx, postsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post counters from cache: %w", err)
+	}
+
+	if len(missedPostsIDs) == 0 {
+		return counters, nil
+	}
+
+	commentsCounts, err := h.CommentRepository.GetCountsByPostIDs(ctx, missedPostsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments counts from repo: %w", err)
+	}
+
+	sharesCounts, err := h.PostRepository.GetSharesCountsByPostIDs(ctx, missedPostsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shares counts from repo: %w", err)
+	}
+
+	for _, postID := range missedPostsIDs {
+		counters[postID] = postcache.Counters{
+			Comments: commentsCounts[postID],
+			Shares:   sharesCounts[postID],
+		}
+	}
+
+	err = h.PostCache.SetCounters(ctx, missedPostsIDs, counters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set post counters to cache: %w", err)
+	}
+
+	return counters, nil
+}
+
+func (h *PostHydrator) getPolls(ctx context.Context, userID string, postsIDs []string) (map[string]*pollResponse, error) {
+	polls, missedPostsIDs, err := h.PostPollCache.GetPolls(ctx, postsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get polls from cache: %w", err)
+	}
+
+	if len(missedPostsIDs) > 0 {
+		missedPolls, err := h.PollRepository.GetPollsByPostIDs(ctx, missedPostsIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get polls from repo: %w", err)
+		}
+
+		for postID, poll := range missedPolls {
+			polls[postID] = poll
+		}
+
+		err = h.PostPollCache.SetPolls(ctx, missedPostsIDs, missedPolls)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set polls to cache: %w", err)
+		}
+	}
+
+	if len(polls) == 0 {
+		return nil, nil
+	}
+
+	pollsPostsIDs := make([]string, 0, len(polls))
+	for postID := range polls {
+		pollsPostsIDs = append(pollsPostsIDs, postID)
+	}
+
+	userVotes, err := h.getUserVotes(ctx, userID, pollsPostsIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := h.getPollsCounts(ctx, pollsPostsIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	pollsResponse := make(map[string]*pollResponse, len(polls))
+
+	for postID, poll := range polls {
+		pollResp := &pollResponse{
+			MultipleChoice: poll.MultipleChoice,
+			ClosesAt:       poll.ClosesAt,
+			Closed:         poll.IsClosed(),
+			Options:        make([]pollOptionResponse, 0, len(poll.Options)),
+			MyVotes:        userVotes[postID],
+		}
+
+		showResults := pollResp.Closed || len(pollResp.MyVotes) > 0
+
+		if showResults {
+			votersCount := counts[postID].Voters
+			pollResp.VotersCount = &votersCount
+		}
+
+		for _, option := range poll.Options {
+			optionResp := pollOptionResponse{
+				ID:   option.ID,
+				Text: option.Text,
+			}
+
+			if showResults {
+				votesCount := counts[postID].Options[option.ID]
+				optionResp.VotesCount = &votesCount
+			}
+
+			pollResp.Options = append(pollResp.Options, optionResp)
+		}
+
+		pollsResponse[postID] = pollResp
+	}
+
+	return pollsResponse, nil
+}
+
+func (h *PostHydrator) getUserVotes(ctx context.Context, userID string, postsIDs []string) (map[string][]int, error) {
+	votes, missedPostsIDs, err := h.PostPollCache.GetUserVotes(ctx, userID, postsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user poll votes from cache: %w", err)
+	}
+
+	if len(missedPostsIDs) == 0 {
+		return votes, nil
+	}
+
+	missedVotes, err := h.PollRepository.GetUserVotesByPostIDs(ctx, userID, missedPostsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user poll votes from repo: %w", err)
+	}
+
+	for postID, optionIDs := range missedVotes {
+		votes[postID] = optionIDs
+	}
+
+	err = h.PostPollCache.FillUserVotes(ctx, userID, missedPostsIDs, missedVotes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set user poll votes to cache: %w", err)
+	}
+
+	return votes, nil
+}
+
+func (h *PostHydrator) getPollsCounts(ctx context.Context, postsIDs []string) (map[string]repository.PollCounts, error) {
+	counts, missedPostsIDs, err := h.PostPollCache.GetCounts(ctx, postsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get polls counts from cache: %w", err)
+	}
+
+	if len(missedPostsIDs) == 0 {
+		return counts, nil
+	}
+
+	missedCounts, err := h.PollRepository.GetCountsByPostIDs(ctx, missedPostsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get polls counts from repo: %w", err)
+	}
+
+	for postID, postCounts := range missedCounts {
+		counts[postID] = postCounts
+
+		err := h.PostPollCache.SetCounts(ctx, postID, postCounts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set polls counts to cache: %w", err)
+		}
+	}
+
+	return counts, nil
+}
+
+func (h *PostHydrator) getOriginalPosts(ctx context.Context, userID string, posts []repository.Post) (map[string]*originalPostResponse, error) {
+	var originalPostsIDs []string
+
+	for _, post := range posts {
+		if post.OriginalPostID != "" {
+			originalPostsIDs = append(originalPostsIDs, post.OriginalPostID)
+		}
+	}
+
+	if len(originalPostsIDs) == 0 {
+		return nil, nil
+	}
+
+	originalPosts, err := h.PostRepository.GetPostsByIDs(ctx, originalPostsIDs, 0, len(originalPostsIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get original posts from repo: %w", err)
+	}
+
+	originalPosts, err = h.PostVisibilityChecker.FilterVisible(ctx, userID, originalPosts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter visible original posts: %w", err)
+	}
+
+	authorsIDs := make([]string, 0, len(originalPosts))
+	for _, originalPost := range originalPosts {
+		authorsIDs = append(authorsIDs, originalPost.AuthorID)
+	}
+
+	authors := make(map[string]repository.User, len(authorsIDs))
+
+	if len(authorsIDs) > 0 {
+		users, err := h.UserRepository.GetUsersByIDs(ctx, authorsIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get original posts authors from repo: %w", err)
+		}
+
+		for _, user := range users {
+			authors[user.ID] = user
+		}
+	}
+
+	originalPostsResponse := make(map[string]*originalPostResponse, len(originalPostsIDs))
+
+	// deleted posts and posts hidden from the user are rendered as unavailable
+	for _, originalPostID := range originalPostsIDs {
+		originalPostsResponse[originalPostID] = &originalPostResponse{
+			ID:          originalPostID,
+			Unavailable: true,
+		}
+	}
+
+	for _, originalPost := range originalPosts {
+		author, ok := authors[originalPost.AuthorID]
+		if !ok {
+			continue
+		}
+
+		originalPostsResponse[originalPost.ID] = &originalPostResponse{
+			ID:   originalPost.ID,
+			Text: originalPost.Text,
+			Author: &postAuthorResponse{
+				ID:         author.ID,
+				FirstName:  author.FirstName,
+				SecondName: author.LastName,
+			},
+		}
+	}
+
+	return originalPostsResponse, nil
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/postviewcache"
+	"myfacebook/internal/repository"
+)
+
+type PostStats struct {
+	PostRepository         repository.PostRepository
+	PostReactionRepository repository.PostReactionRepository
+	PostStatsRepository    repository.PostStatsRepository
+	PostViewCache          *postviewcache.Cache
+}
+
+type postStatsResponse struct {
+	PostID        string         `json:"post_id"`
+	UniqueViewers int64          `json:"unique_viewers"`
+	Reactions     map[string]int `json:"reactions"`
+	SharesCount   int            `json:"shares_count"`
+}
+
+func (h *PostStats) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	post, err := h.PostRepository.GetPostByID(ctx, httprouter.RouteParam(ctx, "id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("post stats handler, failed to get post from repo: %w", err))
+	}
+
+	// reach numbers are for the author only
+	if post.AuthorID != userID {
+		return apiv1.NewEntityNotFoundError(nil)
+	}
+
+	postStatsResp := postStatsResponse{
+		PostID:    post.ID,
+		Reactions: map[string]int{},
+	}
+
+	postStats, err := h.PostStatsRepository.GetPostStatsByPostID(ctx, post.ID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return apiv1.NewServerError(fmt.Errorf("post stats handler, failed to get post stats from repo: %w", err))
+	}
+
+	if postStats != nil {
+		postStatsResp.UniqueViewers = postStats.UniqueViewers
+	}
+
+	// views recorded since the last flush are only in the cache
+	uniqueViewers, err := h.PostViewCache.GetUniqueViewers(ctx, []string{post.ID})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("post stats handler, failed to get unique viewers from cache: %w", err))
+	}
+
+	if uniqueViewers[post.ID] > postStatsResp.UniqueViewers {
+		postStatsResp.UniqueViewers = uniqueViewers[post.ID]
+	}
+
+	reactionsCounts, err := h.PostReactionRepository.GetCountsByPostIDs(ctx, []string{post.ID})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("post stats handler, failed to get reactions counts from repo: %w", err))
+	}
+
+	if postReactionsCounts, ok := reactionsCounts[post.ID]; ok {
+		postStatsResp.Reactions = postReactionsCounts
+	}
+
+	sharesCounts, err := h.PostRepository.GetSharesCountsByPostIDs(ctx, []string{post.ID})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("post stats handler, failed to get shares counts from repo: %w", err))
+	}
+
+	postStatsResp.SharesCount = sharesCounts[post.ID]
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(&postStatsResp)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("post stats handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/repository"
+)
+
+const maxPostTrashLimit = 100
+
+type PostTrash struct {
+	PostRepository repository.PostRepository
+	PostHydrator   *PostHydrator
+}
+
+type postTrashRequest struct {
+	Cursor *repository.Cursor
+	Limit  int
+}
+
+func (h *PostTrash) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	postTrashReq, err := h.getPostTrashRequest(request)
+	if err != nil {
+		return err
+	}
+
+	posts, err := h.PostRepository.GetDeletedPostsByAuthorID(ctx, userID, postTrashReq.Cursor, postTrashReq.Limit)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("post trash handler, failed to get deleted posts from repo: %w", err))
+	}
+
+	postsResponse, err := h.PostHydrator.hydrate(ctx, userID, posts)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("post trash handler, failed to hydrate posts: %w", err))
+	}
+
+	postTrashResp := postsPageResponse{
+		Posts: postsResponse,
+	}
+
+	if len(posts) == postTrashReq.Limit {
+		lastPost := posts[len(posts)-1]
+
+		postTrashResp.NextCursor = encodeCursor(repository.Cursor{
+			CreatedAt: lastPost.CreatedAt,
+			ID:        lastPost.ID,
+		})
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(&postTrashResp)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("post trash handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
+
+func (h *PostTrash) getPostTrashRequest(request *http.Request) (postTrashRequest, error) {
+	postTrashReq := postTrashRequest{
+		Limit: 10,
+	}
+
+	if cursor := request.URL.Query().Get("cursor"); cursor != "" {
+		decodedCursor, err := decodeCursor(cursor)
+		if err != nil {
+			return postTrashReq, apiv1.NewInvalidRequestErrorInvalidParameter("cursor",
+				fmt.Errorf("post trash handler, failed to decode cursor %q: %w", cursor, err))
+		}
+
+		postTrashReq.Cursor = decodedCursor
+	}
+
+	if request.URL.Query().Get("limit") != "" {
+		limit, err := strconv.Atoi(request.URL.Query().Get("limit"))
+		if err != nil {
+			return postTrashReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit",
+				fmt.Errorf("post trash handler, failed to convert limit %q to int: %w", request.URL.Query().Get("limit"), err))
+		}
+
+		if limit <= 0 || limit > maxPostTrashLimit {
+			return postTrashReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit", nil)
+		}
+
+		postTrashReq.Limit = limit
+	}
+
+	return postTrashReq, nil
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/repository"
+)
+
+type PublishPostDraft struct {
+	PostDraftRepository repository.PostDraftRepository
+	CreatePost          *CreatePost
+}
+
+type publishPostDraftRequest struct {
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+func (h *PublishPostDraft) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	// the request body is optional
+	var publishPostDraftReq publishPostDraftRequest
+	if err := json.NewDecoder(request.Body).Decode(&publishPostDraftReq); err != nil && !errors.Is(err, io.EOF) {
+		return apiv1.NewServerError(fmt.Errorf("publish post draft handler, cannot decode request body: %w", err))
+	}
+
+	defer request.Body.Close()
+
+	ctx := request.Context()
+
+	authorID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	// the draft is taken first, so publishing it twice at once does not create two posts
+	draft, err := h.PostDraftRepository.Claim(ctx, httprouter.RouteParam(ctx, "id"), authorID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("publish post draft handler, failed to claim post draft: %w", err))
+	}
+
+	// the draft becomes a regular post, so feeds get it exactly as if it was created right now
+	post, err := h.CreatePost.create(ctx, authorID, postRequest{
+		Text:       draft.Text,
+		Visibility: draft.Visibility,
+		Audience:   draft.Audience,
+		PublishAt:  publishPostDraftReq.PublishAt,
+	})
+	if err != nil {
+		// the draft is given back, so the author can fix it and publish again
+		if addErr := h.PostDraftRepository.Add(ctx, *draft, time.Until(draft.ExpiresAt)); addErr != nil {
+			return apiv1.NewServerError(fmt.Errorf("publish post draft handler, failed to give back post draft: %w",
+				errors.Join(err, addErr)))
+		}
+
+		return err
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(postResponse{
+		ID:     post.ID,
+		Status: post.Status,
+	})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("publish post draft handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/postreactioncache"
+	"myfacebook/internal/postvisibility"
+	"myfacebook/internal/repository"
+	"myfacebook/internal/rmq"
+)
+
+var postReactionTypes = map[string]struct{}{
+	"like":  {},
+	"love":  {},
+	"haha":  {},
+	"wow":   {},
+	"sad":   {},
+	"angry": {},
+}
+
+type ReactPost struct {
+	PostRepository         repository.PostRepository
+	PostVisibilityChecker  *postvisibility.Checker
+	PostReactionRepository repository.PostReactionRepository
+	PostReactionCache      *postreactioncache.Cache
+	RMQ                    *rmq.RMQ
+}
+
+type reactPostRequest struct {
+	Type string `json:"type"`
+}
+
+func (h *ReactPost) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	var reactPostReq reactPostRequest
+	if err := json.NewDecoder(request.Body).Decode(&reactPostReq); err != nil {
+		return apiv1.NewServerError(fmt.Errorf("react post handler, cannot decode request body: %w", err))
+	}
+
+	defer request.Body.Close()
+
+	if reactPostReq.Type == "" {
+		return apiv1.NewInvalidRequestErrorMissingRequiredParameter("type")
+	}
+
+	if _, ok := postReactionTypes[reactPostReq.Type]; !ok {
+		return apiv1.NewInvalidRequestErrorInvalidParameter("type", nil)
+	}
+
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	postID := httprouter.RouteParam(ctx, "id")
+
+	post, err := h.PostRepository.GetPostByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("react post handler, failed to get post from repo: %w", err))
+	}
+
+	canView, err := h.PostVisibilityChecker.CanView(ctx, userID, *post)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("react post handler, failed to check post visibility: %w", err))
+	}
+
+	if !canView {
+		return apiv1.NewEntityNotFoundError(nil)
+	}
+
+	prevReactionType, err := h.PostReactionRepository.Set(ctx, repository.PostReaction{
+		PostID: post.ID,
+		UserID: userID,
+		Type:   reactPostReq.Type,
+	})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("react post handler, failed to set post reaction: %w", err))
+	}
+
+	err = h.PostReactionCache.SetUserReaction(ctx, userID, post.ID, reactPostReq.Type)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("react post handler, %w", err))
+	}
+
+	if prevReactionType != reactPostReq.Type {
+		if prevReactionType != "" {
+			err = h.PostReactionCache.Incr(ctx, post.ID, prevReactionType, -1)
+			if err != nil {
+				return apiv1.NewServerError(fmt.Errorf("react post handler, failed to decrement reaction count: %w", err))
+			}
+		}
+
+		err = h.PostReactionCache.Incr(ctx, post.ID, reactPostReq.Type, 1)
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("react post handler, failed to increment reaction count: %w", err))
+		}
+
+		err = publishNotification(ctx, h.RMQ, post.AuthorID, notificationRMQMessage{
+			Type:     "post_reaction",
+			ActorID:  userID,
+			PostID:   post.ID,
+			Reaction: reactPostReq.Type,
+		})
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("react post handler, %w", err))
+		}
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
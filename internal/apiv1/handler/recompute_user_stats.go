@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/userstats"
+)
+
+// RecomputeUserStats counts the users followers from scratch, e.g. when the counts drifted
+// or the celebrity thresholds were changed.
+type RecomputeUserStats struct {
+	UserStatsCounter *userstats.Counter
+}
+
+type recomputeUserStatsResponse struct {
+	UsersCount int `json:"users_count"`
+}
+
+func (h *RecomputeUserStats) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	usersCount, err := h.UserStatsCounter.Recompute(ctx)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("recompute user stats handler, failed to recompute: %w", err))
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(&recomputeUserStatsResponse{
+		UsersCount: usersCount,
+	})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("recompute user stats handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/repository"
+)
+
+const maxReportsLimit = 100
+
+type Reports struct {
+	ReportRepository repository.ReportRepository
+}
+
+type reportsRequest struct {
+	Status string
+	Offset int
+	Limit  int
+}
+
+type reportResponse struct {
+	ID             string     `json:"id"`
+	Type           string     `json:"type"`
+	TargetID       string     `json:"target_id"`
+	TargetAuthorID string     `json:"target_author_id"`
+	ReporterID     string     `json:"reporter_id"`
+	Reason         string     `json:"reason"`
+	Comment        string     `json:"comment,omitempty"`
+	Status         string     `json:"status"`
+	AssigneeID     string     `json:"assignee_id,omitempty"`
+	Action         string     `json:"action,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+}
+
+func (h *Reports) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	reportsReq, err := h.getReportsRequest(request)
+	if err != nil {
+		return err
+	}
+
+	reports, err := h.ReportRepository.GetReportsByStatus(ctx, reportsReq.Status, reportsReq.Offset, reportsReq.Limit)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("reports handler, failed to get reports from repo: %w", err))
+	}
+
+	reportsResponse := make([]reportResponse, 0, len(reports))
+
+	for _, report := range reports {
+		reportsResponse = append(reportsResponse, reportResponse{
+			ID:             report.ID,
+			Type:           report.TargetType,
+			TargetID:       report.TargetID,
+			TargetAuthorID: report.TargetAuthorID,
+			ReporterID:     report.ReporterID,
+			Reason:         report.Reason,
+			Comment:        report.Comment,
+			Status:         report.Status,
+			AssigneeID:     report.AssigneeID,
+			Action:         report.Action,
+			CreatedAt:      report.CreatedAt,
+			ResolvedAt:     report.ResolvedAt,
+		})
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(reportsResponse)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("reports handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
+
+func (h *Reports) getReportsRequest(request *http.Request) (reportsRequest, error) {
+	reportsReq := reportsRequest{
+		Status: repository.ReportStatusOpen,
+		Limit:  10,
+	}
+
+	if status := request.URL.Query().Get("status"); status != "" {
+		if status != repository.ReportStatusOpen && status != repository.ReportStatusClaimed && status != repository.ReportStatusResolved {
+			return reportsReq, apiv1.NewInvalidRequestErrorInvalidParameter("status", nil)
+		}
+
+		reportsReq.Status = status
+	}
+
+	if request.URL.Query().Get("offset") != "" {
+		offset, err := strconv.Atoi(request.URL.Query().Get("offset"))
+		if err != nil {
+			return reportsReq, apiv1.NewInvalidRequestErrorInvalidParameter("offset",
+				fmt.Errorf("reports handler, failed to convert offset %q to int: %w", request.URL.Query().Get("offset"), err))
+		}
+
+		if offset < 0 {
+			return reportsReq, apiv1.NewInvalidRequestErrorInvalidParameter("offset", nil)
+		}
+
+		reportsReq.Offset = offset
+	}
+
+	if request.URL.Query().Get("limit") != "" {
+		limit, err := strconv.Atoi(request.URL.Query().Get("limit"))
+		if err != nil {
+			return reportsReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit",
+				fmt.Errorf("reports handler, failed to convert limit %q to int: %w", request.URL.Query().Get("limit"), err))
+		}
+
+		if limit <= 0 || limit > maxReportsLimit {
+			return reportsReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit", nil)
+		}
+
+		reportsReq.Limit = limit
+	}
+
+	return reportsReq, nil
+}
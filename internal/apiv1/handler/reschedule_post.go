@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/repository"
+)
+
+type ReschedulePost struct {
+	PostRepository repository.PostRepository
+}
+
+type reschedulePostRequest struct {
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+func (h *ReschedulePost) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	var reschedulePostReq reschedulePostRequest
+	if err := json.NewDecoder(request.Body).Decode(&reschedulePostReq); err != nil {
+		return apiv1.NewServerError(fmt.Errorf("reschedule post handler, cannot decode request body: %w", err))
+	}
+
+	defer request.Body.Close()
+
+	if reschedulePostReq.PublishAt == nil {
+		return apiv1.NewInvalidRequestErrorMissingRequiredParameter("publish_at")
+	}
+
+	if !reschedulePostReq.PublishAt.After(time.Now()) {
+		return apiv1.NewInvalidRequestErrorInvalidParameter("publish_at", nil)
+	}
+
+	ctx := request.Context()
+
+	authorID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	err := h.PostRepository.Reschedule(ctx, httprouter.RouteParam(ctx, "id"), authorID, reschedulePostReq.PublishAt.UTC())
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("reschedule post handler, failed to reschedule post: %w", err))
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/config"
+	"myfacebook/internal/postcache"
+	"myfacebook/internal/repository"
+	"myfacebook/internal/rmq"
+)
+
+type ResolveReport struct {
+	ReportRepository  repository.ReportRepository
+	PostRepository    repository.PostRepository
+	CommentRepository repository.CommentRepository
+	UserRepository    repository.UserRepository
+	PostCache         *postcache.Cache
+	RMQ               *rmq.RMQ
+	EnvConfig         *config.EnvConfig
+}
+
+type resolveReportRequest struct {
+	Action string `json:"action"`
+}
+
+func (h *ResolveReport) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	var resolveReportReq resolveReportRequest
+	if err := json.NewDecoder(request.Body).Decode(&resolveReportReq); err != nil {
+		return apiv1.NewServerError(fmt.Errorf("resolve report handler, cannot decode request body: %w", err))
+	}
+
+	defer request.Body.Close()
+
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	report, err := h.ReportRepository.GetReportByID(ctx, httprouter.RouteParam(ctx, "id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("resolve report handler, failed to get report from repo: %w", err))
+	}
+
+	if report.Status == repository.ReportStatusResolved {
+		return apiv1.NewEntityNotFoundError(nil)
+	}
+
+	// a claimed report is resolved by the moderator who claimed it
+	if report.Status == repository.ReportStatusClaimed && report.AssigneeID != userID {
+		return apiv1.NewForbiddenError()
+	}
+
+	switch resolveReportReq.Action {
+	case "":
+		return apiv1.NewInvalidRequestErrorMissingRequiredParameter("action")
+	case repository.ReportActionDismiss:
+		err = h.restoreTarget(ctx, *report)
+	case repository.ReportActionDeleteContent:
+		if report.TargetType != repository.ReportTargetPost && report.TargetType != repository.ReportTargetComment {
+			return apiv1.NewInvalidRequestErrorInvalidParameter("action", nil)
+		}
+
+		err = h.deleteTarget(ctx, *report)
+	case repository.ReportActionBanUser:
+		err = h.banTargetAuthor(ctx, *report)
+	default:
+		return apiv1.NewInvalidRequestErrorInvalidParameter("action", nil)
+	}
+
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("resolve report handler, %w", err))
+	}
+
+	err = h.ReportRepository.ResolveByTarget(ctx, report.TargetType, report.TargetID, userID, resolveReportReq.Action)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("resolve report handler, %w", err))
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
+
+// restoreTarget brings back the content hidden because of the reports.
+func (h *ResolveReport) restoreTarget(ctx context.Context, report repository.Report) error {
+	reportsCount, err := h.ReportRepository.GetPendingCountByTarget(ctx, report.TargetType, report.TargetID)
+	if err != nil {
+		return fmt.Errorf("failed to get pending reports count: %w", err)
+	}
+
+	if reportsCount < h.EnvConfig.ReportHideThreshold {
+		return nil
+	}
+
+	switch report.TargetType {
+	case repository.ReportTargetPost:
+		// a post held by the moderation pipeline stays held until a moderator approves it
+		post, err := h.PostRepository.ReleaseReportHold(ctx, report.TargetID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil
+			}
+
+			return fmt.Errorf("failed to approve reported post: %w", err)
+		}
+
+		if post.Status == repository.PostStatusPublished {
+			return publishPostFeedRMQMessage(ctx, h.RMQ, "add", *post)
+		}
+	case repository.ReportTargetComment:
+		err := h.CommentRepository.Unhide(ctx, report.TargetID)
+		if err != nil {
+			return fmt.Errorf("failed to unhide reported comment: %w", err)
+		}
+
+		return h.dropCommentPostCounters(ctx, report.TargetID)
+	}
+
+	return nil
+}
+
+func (h *ResolveReport) deleteTarget(ctx context.Context, report repository.Report) error {
+	switch report.TargetType {
+	case repository.ReportTargetPost:
+		post, err := h.PostRepository.RemoveByModeration(ctx, report.TargetID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil
+			}
+
+			return fmt.Errorf("failed to delete reported post: %w", err)
+		}
+
+		if post.OriginalPostID != "" {
+			err = h.PostCache.DeleteCounters(ctx, post.OriginalPostID)
+			if err != nil {
+				return fmt.Errorf("failed to drop counters of reported post original: %w", err)
+			}
+		}
+
+		return publishPostFeedRMQMessage(ctx, h.RMQ, "remove", repository.Post{
+			ID:       report.TargetID,
+			AuthorID: report.TargetAuthorID,
+		})
+	case repository.ReportTargetComment:
+		comment, err := h.CommentRepository.GetCommentByID(ctx, report.TargetID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil
+			}
+
+			return fmt.Errorf("failed to get reported comment: %w", err)
+		}
+
+		err = h.CommentRepository.Delete(ctx, comment.ID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("failed to delete reported comment: %w", err)
+		}
+
+		err = h.PostCache.DeleteCounters(ctx, comment.PostID)
+		if err != nil {
+			return fmt.Errorf("failed to drop counters of reported comment post: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// banTargetAuthor bans the author together with removing the reported content.
+func (h *ResolveReport) banTargetAuthor(ctx context.Context, report repository.Report) error {
+	err := h.UserRepository.Ban(ctx, report.TargetAuthorID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to ban user: %w", err)
+	}
+
+	return h.deleteTarget(ctx, report)
+}
+
+// dropCommentPostCounters drops the cached counters of the post the comment belongs to, so the comments count is reloaded.
+func (h *ResolveReport) dropCommentPostCounters(ctx context.Context, commentID string) error {
+	comment, err := h.CommentRepository.GetCommentByID(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to get reported comment: %w", err)
+	}
+
+	err = h.PostCache.DeleteCounters(ctx, comment.PostID)
+	if err != nil {
+		return fmt.Errorf("failed to drop counters of reported comment post: %w", err)
+	}
+
+	return nil
+}
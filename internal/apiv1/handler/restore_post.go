@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/postcache"
+	"myfacebook/internal/repository"
+	"myfacebook/internal/rmq"
+)
+
+type RestorePost struct {
+	PostRepository repository.PostRepository
+	PostCache      *postcache.Cache
+	RMQ            *rmq.RMQ
+}
+
+func (h *RestorePost) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	authorID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	postID := httprouter.RouteParam(ctx, "id")
+
+	post, err := h.PostRepository.Restore(ctx, postID, authorID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("restore post handler, failed to restore post: %w", err))
+	}
+
+	if post.OriginalPostID != "" {
+		err = h.PostCache.DeleteCounters(ctx, post.OriginalPostID)
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("restore post handler, %w", err))
+		}
+	}
+
+	// repopulate followers' feeds
+	if post.Status == repository.PostStatusPublished {
+		err = publishPostFeedRMQMessage(ctx, h.RMQ, "add", *post)
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("restore post handler, %w", err))
+		}
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
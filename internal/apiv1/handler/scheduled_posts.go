@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/repository"
+)
+
+type ScheduledPosts struct {
+	PostRepository repository.PostRepository
+}
+
+type scheduledPostResponse struct {
+	ID         string    `json:"id"`
+	Text       string    `json:"text"`
+	Visibility string    `json:"visibility"`
+	PublishAt  time.Time `json:"publish_at"`
+}
+
+func (h *ScheduledPosts) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	authorID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	posts, err := h.PostRepository.GetScheduledPostsByAuthorID(ctx, authorID)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("scheduled posts handler, failed to get scheduled posts from repo: %w", err))
+	}
+
+	scheduledPostsResponse := make([]scheduledPostResponse, 0, len(posts))
+
+	for _, post := range posts {
+		scheduledPostResp := scheduledPostResponse{
+			ID:         post.ID,
+			Text:       post.Text,
+			Visibility: post.Visibility,
+		}
+
+		if post.PublishAt != nil {
+			scheduledPostResp.PublishAt = *post.PublishAt
+		}
+
+		scheduledPostsResponse = append(scheduledPostsResponse, scheduledPostResp)
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(scheduledPostsResponse)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("scheduled posts handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
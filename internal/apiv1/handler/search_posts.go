@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/repository"
+)
+
+const maxSearchPostsLimit = 100
+
+type SearchPosts struct {
+	PostRepository repository.PostRepository
+	PostHydrator   *PostHydrator
+}
+
+type searchPostsRequest struct {
+	Query  string
+	Offset int
+	Limit  int
+}
+
+type searchPostResponse struct {
+	getPostResponse
+	Snippet string `json:"snippet"`
+}
+
+type searchPostsResponse struct {
+	Posts []searchPostResponse `json:"posts"`
+}
+
+func (h *SearchPosts) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	searchPostsReq, err := h.getSearchPostsRequest(request)
+	if err != nil {
+		return err
+	}
+
+	// the visibility is checked by the search itself, so a page is not cut down after it is taken
+	results, err := h.PostRepository.SearchPosts(ctx, userID, searchPostsReq.Query, searchPostsReq.Offset, searchPostsReq.Limit)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("search posts handler, failed to search posts in repo: %w", err))
+	}
+
+	posts := make([]repository.Post, 0, len(results))
+	snippets := make(map[string]string, len(results))
+
+	for _, result := range results {
+		posts = append(posts, result.Post)
+		snippets[result.ID] = result.Snippet
+	}
+
+	postsResponse, err := h.PostHydrator.hydrate(ctx, userID, posts)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("search posts handler, failed to hydrate posts: %w", err))
+	}
+
+	searchPostsResp := searchPostsResponse{
+		Posts: make([]searchPostResponse, 0, len(postsResponse)),
+	}
+
+	for _, postResponse := range postsResponse {
+		searchPostsResp.Posts = append(searchPostsResp.Posts, searchPostResponse{
+			getPostResponse: postResponse,
+			Snippet:         snippets[postResponse.ID],
+		})
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(&searchPostsResp)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("search posts handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
+
+func (h *SearchPosts) getSearchPostsRequest(request *http.Request) (searchPostsRequest, error) {
+	searchPostsReq := searchPostsRequest{
+		Query: strings.TrimSpace(request.URL.Query().Get("q")),
+		Limit: 10,
+	}
+
+	if searchPostsReq.Query == "" {
+		return searchPostsReq, apiv1.NewInvalidRequestErrorMissingRequiredParameter("q")
+	}
+
+	if request.URL.Query().Get("offset") != "" {
+		offset, err := strconv.Atoi(request.URL.Query().Get("offset"))
+		if err != nil {
+			return searchPostsReq, apiv1.NewInvalidRequestErrorInvalidParameter("offset",
+				fmt.Errorf("search posts handler, failed to convert offset %q to int: %w", request.URL.Query().Get("offset"), err))
+		}
+
+		if offset < 0 {
+			return searchPostsReq, apiv1.NewInvalidRequestErrorInvalidParameter("offset", nil)
+		}
+
+		searchPostsReq.Offset = offset
+	}
+
+	if request.URL.Query().Get("limit") != "" {
+		limit, err := strconv.Atoi(request.URL.Query().Get("limit"))
+		if err != nil {
+			return searchPostsReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit",
+				fmt.Errorf("search posts handler, failed to convert limit %q to int: %w", request.URL.Query().Get("limit"), err))
+		}
+
+		if limit <= 0 || limit > maxSearchPostsLimit {
+			return searchPostsReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit", nil)
+		}
+
+		searchPostsReq.Limit = limit
+	}
+
+	return searchPostsReq, nil
+}
@@ -7,11 +7,13 @@ import (
 
 	"github.com/inbugay1/httprouter"
 	"myfacebook/internal/apiv1"
+	"myfacebook/internal/moderation"
 	"myfacebook/internal/repository"
 )
 
 type SendDialog struct {
-	DialogRepository repository.DialogRepository
+	DialogRepository   repository.DialogRepository
+	ModerationPipeline *moderation.Pipeline
 }
 
 type sendDialogRequest struct {
@@ -35,15 +37,27 @@ func (h *SendDialog) Handle(responseWriter http.ResponseWriter, request *http.Re
 	senderID := ctx.Value("user_id").(string)
 	receiverID := httprouter.RouteParam(ctx, "user_id") // todo validate
 
+	moderationContent := moderation.Content{
+		Kind:     moderation.ContentKindMessage,
+		AuthorID: senderID,
+		Text:     sendDialogReq.Text,
+	}
+
+	err := moderateWithoutReview(ctx, h.ModerationPipeline, moderationContent)
+	if err != nil {
+		return err
+	}
+
 	dialogMsg := repository.DialogMessage{
 		From: senderID,
 		To:   receiverID,
 		Text: sendDialogReq.Text,
 	}
 
-	err := h.DialogRepository.Add(ctx, dialogMsg)
+	err = h.DialogRepository.Add(ctx, dialogMsg)
 	if err != nil {
-		return apiv1.NewServerError(fmt.Errorf("send dialog handler failed to add dialog message to repository: %w", err))
+		return releaseModerated(ctx, h.ModerationPipeline, moderationContent,
+			fmt.Errorf("send dialog handler failed to add dialog message to repository: %w", err))
 	}
 
 	responseWriter.Header().Set("Content-Type", "application/json; utf-8")
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofrs/uuid"
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/moderation"
+	"myfacebook/internal/postcache"
+	"myfacebook/internal/postvisibility"
+	"myfacebook/internal/repository"
+	"myfacebook/internal/rmq"
+)
+
+type SharePost struct {
+	PostRepository        repository.PostRepository
+	UserRepository        repository.UserRepository
+	PostVisibilityChecker *postvisibility.Checker
+	ModerationPipeline    *moderation.Pipeline
+	PostCache             *postcache.Cache
+	RMQ                   *rmq.RMQ
+}
+
+type sharePostRequest struct {
+	Text string `json:"text"`
+}
+
+func (h *SharePost) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	var sharePostReq sharePostRequest
+	if err := json.NewDecoder(request.Body).Decode(&sharePostReq); err != nil {
+		return apiv1.NewServerError(fmt.Errorf("share post handler, cannot decode request body: %w", err))
+	}
+
+	defer request.Body.Close()
+
+	ctx := request.Context()
+
+	authorID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	originalPost, err := h.PostRepository.GetPostByID(ctx, httprouter.RouteParam(ctx, "id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("share post handler, failed to get post from repo: %w", err))
+	}
+
+	canView, err := h.PostVisibilityChecker.CanView(ctx, authorID, *originalPost)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("share post handler, failed to check post visibility: %w", err))
+	}
+
+	if !canView || originalPost.Status == repository.PostStatusScheduled {
+		return apiv1.NewEntityNotFoundError(nil)
+	}
+
+	// sharing a share references the post that was shared in the first place
+	originalPostID := originalPost.ID
+	if originalPost.OriginalPostID != "" {
+		originalPostID = originalPost.OriginalPostID
+	}
+
+	// the share text is checked like the text of a new post
+	moderationContent := moderation.Content{
+		Kind:     moderation.ContentKindPost,
+		AuthorID: authorID,
+		Text:     sharePostReq.Text,
+	}
+
+	verdict, err := moderate(ctx, h.ModerationPipeline, moderationContent)
+	if err != nil {
+		return err
+	}
+
+	postUUIDv4, err := uuid.NewV4()
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("share post handler, failed to generate post uuid: %w", err))
+	}
+
+	post := repository.Post{
+		ID:             postUUIDv4.String(),
+		Text:           sharePostReq.Text,
+		AuthorID:       authorID,
+		OriginalPostID: originalPostID,
+		Visibility:     repository.PostVisibilityPublic,
+		Status:         repository.PostStatusPublished,
+		CreatedAt:      newPostCreatedAt(),
+	}
+
+	if verdict == moderation.VerdictHold {
+		post.Status = repository.PostStatusHeld
+		post.HeldReason = repository.PostHeldReasonModeration
+	}
+
+	err = h.PostRepository.Add(ctx, post)
+	if err != nil {
+		return releaseModerated(ctx, h.ModerationPipeline, moderationContent,
+			fmt.Errorf("share post handler, failed to add post: %w", err))
+	}
+
+	err = h.PostCache.DeleteCounters(ctx, post.OriginalPostID)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("share post handler, %w", err))
+	}
+
+	err = savePostEntities(ctx, h.PostRepository, h.UserRepository, h.RMQ, authorID, post)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("share post handler, %w", err))
+	}
+
+	// a held share is fanned out and announced to the original author once approved
+	if post.Status == repository.PostStatusPublished {
+		err = publishPostFeedRMQMessage(ctx, h.RMQ, "add", post)
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("share post handler, %w", err))
+		}
+
+		err = publishNotification(ctx, h.RMQ, originalPost.AuthorID, notificationRMQMessage{
+			Type:    "post_share",
+			ActorID: authorID,
+			PostID:  post.ID,
+		})
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("share post handler, %w", err))
+		}
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(postResponse{
+		ID:     post.ID,
+		Status: post.Status,
+	})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("share post handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/postvisibility"
+	"myfacebook/internal/repository"
+)
+
+const maxTagPostsLimit = 100
+
+type TagPosts struct {
+	PostRepository        repository.PostRepository
+	PostVisibilityChecker *postvisibility.Checker
+	PostHydrator          *PostHydrator
+}
+
+type tagPostsRequest struct {
+	Tag    string
+	Cursor *repository.Cursor
+	Limit  int
+}
+
+type postsPageResponse struct {
+	Posts      []getPostResponse `json:"posts"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+func (h *TagPosts) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	tagPostsReq, err := h.getTagPostsRequest(request)
+	if err != nil {
+		return err
+	}
+
+	posts, err := h.PostRepository.GetPostsByTag(ctx, tagPostsReq.Tag, tagPostsReq.Cursor, tagPostsReq.Limit)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("tag posts handler, failed to get posts by tag from repo: %w", err))
+	}
+
+	visiblePosts, err := h.PostVisibilityChecker.FilterVisible(ctx, userID, posts)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("tag posts handler, failed to filter visible posts: %w", err))
+	}
+
+	postsResponse, err := h.PostHydrator.hydrate(ctx, userID, visiblePosts)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("tag posts handler, failed to hydrate posts: %w", err))
+	}
+
+	tagPostsResp := postsPageResponse{
+		Posts: postsResponse,
+	}
+
+	if len(posts) == tagPostsReq.Limit {
+		lastPost := posts[len(posts)-1]
+
+		tagPostsResp.NextCursor = encodeCursor(repository.Cursor{
+			CreatedAt: lastPost.CreatedAt,
+			ID:        lastPost.ID,
+		})
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(&tagPostsResp)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("tag posts handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
+
+func (h *TagPosts) getTagPostsRequest(request *http.Request) (tagPostsRequest, error) {
+	tagPostsReq := tagPostsRequest{
+		Tag:   strings.ToLower(httprouter.RouteParam(request.Context(), "name")),
+		Limit: 10,
+	}
+
+	if cursor := request.URL.Query().Get("cursor"); cursor != "" {
+		decodedCursor, err := decodeCursor(cursor)
+		if err != nil {
+			return tagPostsReq, apiv1.NewInvalidRequestErrorInvalidParameter("cursor",
+				fmt.Errorf("tag posts handler, failed to decode cursor %q: %w", cursor, err))
+		}
+
+		tagPostsReq.Cursor = decodedCursor
+	}
+
+	if request.URL.Query().Get("limit") != "" {
+		limit, err := strconv.Atoi(request.URL.Query().Get("limit"))
+		if err != nil {
+			return tagPostsReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit",
+				fmt.Errorf("tag posts handler, failed to convert limit %q to int: %w", request.URL.Query().Get("limit"), err))
+		}
+
+		if limit <= 0 || limit > maxTagPostsLimit {
+			return tagPostsReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit", nil)
+		}
+
+		tagPostsReq.Limit = limit
+	}
+
+	return tagPostsReq, nil
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/postfeedbuilder"
+	"myfacebook/internal/repository"
+)
+
+type UnmuteFriend struct {
+	UserRepository  repository.UserRepository
+	PostFeedBuilder *postfeedbuilder.Builder
+}
+
+func (h *UnmuteFriend) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	friendID := httprouter.RouteParam(ctx, "id")
+
+	err := h.UserRepository.UnmuteFriend(ctx, userID, friendID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("unmute friend handler, failed to unmute friend: %w", err))
+	}
+
+	// the friend posts were not pushed to the feed while muted
+	err = h.PostFeedBuilder.Rebuild(ctx, userID, time.Time{})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("unmute friend handler, failed to rebuild feed: %w", err))
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
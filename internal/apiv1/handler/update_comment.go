@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/moderation"
+	"myfacebook/internal/repository"
+)
+
+type UpdateComment struct {
+	CommentRepository  repository.CommentRepository
+	ModerationPipeline *moderation.Pipeline
+}
+
+type updateCommentRequest struct {
+	Text string `json:"text"`
+}
+
+func (h *UpdateComment) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	var updateCommentReq updateCommentRequest
+	if err := json.NewDecoder(request.Body).Decode(&updateCommentReq); err != nil {
+		return apiv1.NewServerError(fmt.Errorf("update comment handler, cannot decode request body: %w", err))
+	}
+
+	defer request.Body.Close()
+
+	if updateCommentReq.Text == "" {
+		return apiv1.NewInvalidRequestErrorMissingRequiredParameter("text")
+	}
+
+	ctx := request.Context()
+
+	authorID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	err := moderateWithoutReview(ctx, h.ModerationPipeline, moderation.Content{
+		Kind:     moderation.ContentKindComment,
+		AuthorID: authorID,
+		Text:     updateCommentReq.Text,
+		Edit:     true,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = h.CommentRepository.Update(ctx, repository.Comment{
+		ID:       httprouter.RouteParam(ctx, "id"),
+		AuthorID: authorID,
+		Text:     updateCommentReq.Text,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("update comment handler, failed to update comment: %w", err))
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
@@ -6,17 +6,28 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/gofrs/uuid"
 	"myfacebook/internal/apiv1"
+	"myfacebook/internal/moderation"
+	"myfacebook/internal/postcache"
+	"myfacebook/internal/postvisibility"
 	"myfacebook/internal/repository"
+	"myfacebook/internal/rmq"
 )
 
 type UpdatePost struct {
-	PostRepository repository.PostRepository
+	PostRepository     repository.PostRepository
+	UserRepository     repository.UserRepository
+	ModerationPipeline *moderation.Pipeline
+	PostCache          *postcache.Cache
+	RMQ                *rmq.RMQ
 }
 
 type updatePostRequest struct {
-	ID   string `json:"id"`
-	Text string `json:"text"`
+	ID         string   `json:"id"`
+	Text       string   `json:"text"`
+	Visibility string   `json:"visibility"`
+	Audience   []string `json:"audience"`
 }
 
 func (h *UpdatePost) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
@@ -33,16 +44,20 @@ func (h *UpdatePost) Handle(responseWriter http.ResponseWriter, request *http.Re
 		return apiv1.NewInvalidRequestErrorMissingRequiredParameter("id")
 	}
 
+	if _, err := uuid.FromString(updatePostReq.ID); err != nil {
+		return apiv1.NewInvalidRequestErrorInvalidParameter("id", err)
+	}
+
 	if updatePostReq.Text == "" {
 		return apiv1.NewInvalidRequestErrorMissingRequiredParameter("text")
 	}
 
-	post := repository.Post{
-		ID:   updatePostReq.ID,
-		Text: updatePostReq.Text,
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
 	}
 
-	err := h.PostRepository.Update(ctx, post)
+	post, err := h.PostRepository.GetPostByID(ctx, updatePostReq.ID)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return apiv1.NewEntityNotFoundError(err)
@@ -51,6 +66,92 @@ func (h *UpdatePost) Handle(responseWriter http.ResponseWriter, request *http.Re
 		return apiv1.NewServerError(fmt.Errorf("update post handler, failed to get post from repo: %w", err))
 	}
 
+	if post.AuthorID != userID {
+		return apiv1.NewEntityNotFoundError(nil)
+	}
+
+	visibility := post.Visibility
+	if updatePostReq.Visibility != "" {
+		visibility = updatePostReq.Visibility
+	}
+
+	audienceChanged := visibility == repository.PostVisibilityList &&
+		(post.Visibility != repository.PostVisibilityList || updatePostReq.Audience != nil)
+
+	if audienceChanged {
+		if err := validatePostVisibility(visibility, updatePostReq.Audience); err != nil {
+			return err
+		}
+	} else if !postvisibility.IsValid(visibility) {
+		return apiv1.NewInvalidRequestErrorInvalidParameter("visibility", nil)
+	}
+
+	verdict, err := moderate(ctx, h.ModerationPipeline, moderation.Content{
+		Kind:     moderation.ContentKindPost,
+		AuthorID: userID,
+		Text:     updatePostReq.Text,
+		Edit:     true,
+	})
+	if err != nil {
+		return err
+	}
+
+	visibilityChanged := visibility != post.Visibility
+	wasPublished := post.Status == repository.PostStatusPublished
+
+	post.Text = updatePostReq.Text
+	post.Visibility = visibility
+
+	// a post held because of the reports is held by the pipeline too, so dismissing the reports does not release it
+	if verdict == moderation.VerdictHold && (post.Status == repository.PostStatusPublished ||
+		post.Status == repository.PostStatusScheduled || post.Status == repository.PostStatusHeld) {
+		post.Status = repository.PostStatusHeld
+		post.HeldReason = repository.PostHeldReasonModeration
+	}
+
+	err = h.PostRepository.Update(ctx, *post)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("update post handler, failed to update post: %w", err))
+	}
+
+	// the feeds read the changed text from the db until the post is cached again
+	err = h.PostCache.DeletePost(ctx, post.ID)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("update post handler, %w", err))
+	}
+
+	if audienceChanged {
+		err = h.PostRepository.SetAudience(ctx, post.ID, updatePostReq.Audience)
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("update post handler, failed to set post audience: %w", err))
+		}
+	}
+
+	err = savePostEntities(ctx, h.PostRepository, h.UserRepository, h.RMQ, userID, *post)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("update post handler, %w", err))
+	}
+
+	// the post held for review is taken off the feeds until approved
+	if wasPublished && post.Status == repository.PostStatusHeld {
+		err = publishPostFeedRMQMessage(ctx, h.RMQ, "remove", *post)
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("update post handler, %w", err))
+		}
+	}
+
+	// followers' feeds have to follow the new audience of the post
+	if post.Status == repository.PostStatusPublished && (visibilityChanged || audienceChanged) {
+		err = publishPostFeedRMQMessage(ctx, h.RMQ, "update", *post)
+		if err != nil {
+			return apiv1.NewServerError(fmt.Errorf("update post handler, %w", err))
+		}
+	}
+
 	responseWriter.WriteHeader(http.StatusOK)
 
 	return nil
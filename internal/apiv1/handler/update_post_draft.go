@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/config"
+	"myfacebook/internal/repository"
+)
+
+type UpdatePostDraft struct {
+	PostDraftRepository repository.PostDraftRepository
+	EnvConfig           *config.EnvConfig
+}
+
+func (h *UpdatePostDraft) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	var postDraftReq postDraftRequest
+	if err := json.NewDecoder(request.Body).Decode(&postDraftReq); err != nil {
+		return apiv1.NewServerError(fmt.Errorf("update post draft handler, cannot decode request body: %w", err))
+	}
+
+	defer request.Body.Close()
+
+	if err := validatePostDraftRequest(postDraftReq); err != nil {
+		return err
+	}
+
+	ctx := request.Context()
+
+	authorID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	draft := repository.PostDraft{
+		ID:         httprouter.RouteParam(ctx, "id"),
+		AuthorID:   authorID,
+		Text:       postDraftReq.Text,
+		Visibility: postDraftReq.Visibility,
+		Audience:   postDraftReq.Audience,
+	}
+
+	err := h.PostDraftRepository.Update(ctx, draft, time.Duration(h.EnvConfig.PostDraftTTLHours)*time.Hour)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("update post draft handler, failed to update post draft: %w", err))
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
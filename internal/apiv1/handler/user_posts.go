@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/postvisibility"
+	"myfacebook/internal/repository"
+)
+
+const maxUserPostsLimit = 100
+
+type UserPosts struct {
+	UserRepository        repository.UserRepository
+	PostRepository        repository.PostRepository
+	PostVisibilityChecker *postvisibility.Checker
+	PostHydrator          *PostHydrator
+}
+
+type userPostsRequest struct {
+	AuthorID string
+	Cursor   *repository.Cursor
+	Limit    int
+}
+
+func (h *UserPosts) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	userPostsReq, err := h.getUserPostsRequest(request)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.UserRepository.GetUserByID(ctx, userPostsReq.AuthorID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("user posts handler, failed to get user by id from repo: %w", err))
+	}
+
+	posts, err := h.PostRepository.GetPostsByAuthorID(ctx, userPostsReq.AuthorID, userPostsReq.Cursor, userPostsReq.Limit)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("user posts handler, failed to get posts by author id from repo: %w", err))
+	}
+
+	visiblePosts, err := h.PostVisibilityChecker.FilterVisible(ctx, userID, posts)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("user posts handler, failed to filter visible posts: %w", err))
+	}
+
+	postsResponse, err := h.PostHydrator.hydrate(ctx, userID, visiblePosts)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("user posts handler, failed to hydrate posts: %w", err))
+	}
+
+	userPostsResp := postsPageResponse{
+		Posts: postsResponse,
+	}
+
+	if len(posts) == userPostsReq.Limit {
+		lastPost := posts[len(posts)-1]
+
+		userPostsResp.NextCursor = encodeCursor(repository.Cursor{
+			CreatedAt: lastPost.CreatedAt,
+			ID:        lastPost.ID,
+		})
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	responseWriter.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(responseWriter).Encode(&userPostsResp)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("user posts handler, cannot encode response: %w", err))
+	}
+
+	return nil
+}
+
+func (h *UserPosts) getUserPostsRequest(request *http.Request) (userPostsRequest, error) {
+	userPostsReq := userPostsRequest{
+		AuthorID: httprouter.RouteParam(request.Context(), "id"),
+		Limit:    10,
+	}
+
+	if cursor := request.URL.Query().Get("cursor"); cursor != "" {
+		decodedCursor, err := decodeCursor(cursor)
+		if err != nil {
+			return userPostsReq, apiv1.NewInvalidRequestErrorInvalidParameter("cursor",
+				fmt.Errorf("user posts handler, failed to decode cursor %q: %w", cursor, err))
+		}
+
+		userPostsReq.Cursor = decodedCursor
+	}
+
+	if request.URL.Query().Get("limit") != "" {
+		limit, err := strconv.Atoi(request.URL.Query().Get("limit"))
+		if err != nil {
+			return userPostsReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit",
+				fmt.Errorf("user posts handler, failed to convert limit %q to int: %w", request.URL.Query().Get("limit"), err))
+		}
+
+		if limit <= 0 || limit > maxUserPostsLimit {
+			return userPostsReq, apiv1.NewInvalidRequestErrorInvalidParameter("limit", nil)
+		}
+
+		userPostsReq.Limit = limit
+	}
+
+	return userPostsReq, nil
+}
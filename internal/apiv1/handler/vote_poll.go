@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/postpollcache"
+	"myfacebook/internal/postvisibility"
+	"myfacebook/internal/repository"
+)
+
+type VotePoll struct {
+	PostRepository        repository.PostRepository
+	PostVisibilityChecker *postvisibility.Checker
+	PollRepository        repository.PollRepository
+	PostPollCache         *postpollcache.Cache
+}
+
+type votePollRequest struct {
+	OptionIDs []int `json:"option_ids"`
+}
+
+func (h *VotePoll) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	var votePollReq votePollRequest
+	if err := json.NewDecoder(request.Body).Decode(&votePollReq); err != nil {
+		return apiv1.NewServerError(fmt.Errorf("vote poll handler, cannot decode request body: %w", err))
+	}
+
+	defer request.Body.Close()
+
+	if len(votePollReq.OptionIDs) == 0 {
+		return apiv1.NewInvalidRequestErrorMissingRequiredParameter("option_ids")
+	}
+
+	ctx := request.Context()
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok {
+		return apiv1.NewServerError(errUserIDTypeAssertionFailed)
+	}
+
+	post, err := h.PostRepository.GetPostByID(ctx, httprouter.RouteParam(ctx, "id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apiv1.NewEntityNotFoundError(err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("vote poll handler, failed to get post from repo: %w", err))
+	}
+
+	canView, err := h.PostVisibilityChecker.CanView(ctx, userID, *post)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("vote poll handler, failed to check post visibility: %w", err))
+	}
+
+	if !canView {
+		return apiv1.NewEntityNotFoundError(nil)
+	}
+
+	polls, err := h.PollRepository.GetPollsByPostIDs(ctx, []string{post.ID})
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("vote poll handler, failed to get poll from repo: %w", err))
+	}
+
+	poll, ok := polls[post.ID]
+	if !ok {
+		return apiv1.NewEntityNotFoundError(nil)
+	}
+
+	if poll.IsClosed() {
+		return apiv1.NewInvalidRequestError("poll is closed", nil)
+	}
+
+	if !poll.MultipleChoice && len(votePollReq.OptionIDs) > 1 {
+		return apiv1.NewInvalidRequestErrorInvalidParameter("option_ids", nil)
+	}
+
+	pollOptionIDs := make(map[int]struct{}, len(poll.Options))
+	for _, option := range poll.Options {
+		pollOptionIDs[option.ID] = struct{}{}
+	}
+
+	chosenOptionIDs := make(map[int]struct{}, len(votePollReq.OptionIDs))
+
+	for _, optionID := range votePollReq.OptionIDs {
+		if _, ok := pollOptionIDs[optionID]; !ok {
+			return apiv1.NewInvalidRequestErrorInvalidParameter("option_ids", nil)
+		}
+
+		if _, ok := chosenOptionIDs[optionID]; ok {
+			return apiv1.NewInvalidRequestErrorInvalidParameter("option_ids", nil)
+		}
+
+		chosenOptionIDs[optionID] = struct{}{}
+	}
+
+	err = h.PollRepository.Vote(ctx, post.ID, userID, votePollReq.OptionIDs)
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return apiv1.NewInvalidRequestError("already voted", err)
+		}
+
+		return apiv1.NewServerError(fmt.Errorf("vote poll handler, failed to vote: %w", err))
+	}
+
+	err = h.PostPollCache.Vote(ctx, post.ID, votePollReq.OptionIDs)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("vote poll handler, %w", err))
+	}
+
+	err = h.PostPollCache.SetUserVotes(ctx, userID, post.ID, votePollReq.OptionIDs)
+	if err != nil {
+		return apiv1.NewServerError(fmt.Errorf("vote poll handler, %w", err))
+	}
+
+	responseWriter.WriteHeader(http.StatusOK)
+
+	return nil
+}
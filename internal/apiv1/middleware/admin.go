@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/inbugay1/httprouter"
+	"myfacebook/internal/apiv1"
+	"myfacebook/internal/repository"
+)
+
+// Admin lets through admins only, it has to be used after Auth.
+type Admin struct {
+	next httprouter.Handler
+}
+
+func (m *Admin) Handle(responseWriter http.ResponseWriter, request *http.Request) error {
+	role, _ := request.Context().Value("user_role").(string)
+	if role != repository.UserRoleAdmin {
+		return apiv1.NewForbiddenError()
+	}
+
+	err := m.next.Handle(responseWriter, request)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	return nil
+}
+
+func NewAdmin() httprouter.MiddlewareFunc {
+	return func(next httprouter.Handler) httprouter.Handler {
+		return &Admin{
+			next: next,
+		}
+	}
+}
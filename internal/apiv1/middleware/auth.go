@@ -39,7 +39,12 @@ func (m *Auth) Handle(responseWriter http.ResponseWriter, request *http.Request)
 		return apiv1.NewServerError(fmt.Errorf("auth middleware, failed to get user by token: %w", err))
 	}
 
-	ctx = context.WithValue(ctx, "user_id", user.ID) //nolint:revive,staticcheck
+	if user.Banned {
+		return apiv1.NewUserBannedError()
+	}
+
+	ctx = context.WithValue(ctx, "user_id", user.ID)     //nolint:revive,staticcheck
+	ctx = context.WithValue(ctx, "user_role", user.Role) //nolint:revive,staticcheck
 
 	err = m.next.Handle(responseWriter, request.WithContext(ctx))
 	if err != nil {
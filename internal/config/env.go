@@ -54,6 +54,40 @@ type EnvConfig struct {
 
 	PopularFriendUsersCount                   int `env:"POPULAR_FRIEND_USERS_COUNT" envDefault:"100"`
 	PopularFriendPostsRetrieveIntervalMinutes int `env:"POPULAR_FRIEND_POSTS_RETRIEVE_INTERVAL_MINUTES" envDefault:"5"`
+	PopularFriendUsersHysteresisPercent       int `env:"POPULAR_FRIEND_USERS_HYSTERESIS_PERCENT" envDefault:"10"`
+
+	FeedTopRankers              []string `env:"FEED_TOP_RANKERS" envDefault:"weighted" envSeparator:","`
+	FeedTopCandidates           int      `env:"FEED_TOP_CANDIDATES" envDefault:"200"`
+	FeedTopRecencyHalfLifeHours int      `env:"FEED_TOP_RECENCY_HALF_LIFE_HOURS" envDefault:"24"`
+	FeedTopRecencyWeight        float64  `env:"FEED_TOP_RECENCY_WEIGHT" envDefault:"1"`
+	FeedTopAffinityWeight       float64  `env:"FEED_TOP_AFFINITY_WEIGHT" envDefault:"0.5"`
+	FeedTopEngagementWeight     float64  `env:"FEED_TOP_ENGAGEMENT_WEIGHT" envDefault:"0.5"`
+
+	PostFeedMaxLength int `env:"POST_FEED_MAX_LENGTH" envDefault:"1000"`
+	PostFeedTTLHours  int `env:"POST_FEED_TTL_HOURS" envDefault:"168"`
+
+	PostFanoutWorkers     int `env:"POST_FANOUT_WORKERS" envDefault:"4"`
+	PostFanoutPrefetch    int `env:"POST_FANOUT_PREFETCH" envDefault:"32"`
+	PostFanoutChunkSize   int `env:"POST_FANOUT_CHUNK_SIZE" envDefault:"500"`
+	PostFanoutMaxAttempts int `env:"POST_FANOUT_MAX_ATTEMPTS" envDefault:"5"`
+
+	PostReactionsReconcileIntervalSeconds int `env:"POST_REACTIONS_RECONCILE_INTERVAL_SECONDS" envDefault:"60"`
+	PostViewsFlushIntervalSeconds         int `env:"POST_VIEWS_FLUSH_INTERVAL_SECONDS" envDefault:"60"`
+
+	PostTrashRetentionHours       int `env:"POST_TRASH_RETENTION_HOURS" envDefault:"720"`
+	PostTrashPurgeIntervalMinutes int `env:"POST_TRASH_PURGE_INTERVAL_MINUTES" envDefault:"60"`
+
+	PostSchedulerIntervalSeconds int `env:"POST_SCHEDULER_INTERVAL_SECONDS" envDefault:"10"`
+
+	PostDraftTTLHours int `env:"POST_DRAFT_TTL_HOURS" envDefault:"720"`
+
+	ModerationBannedWords       []string `env:"MODERATION_BANNED_WORDS" envSeparator:","`
+	ModerationMaxLinks          int      `env:"MODERATION_MAX_LINKS" envDefault:"3"`
+	ModerationMaxRepeatedChars  int      `env:"MODERATION_MAX_REPEATED_CHARS" envDefault:"10"`
+	ModerationRateLimit         int64    `env:"MODERATION_RATE_LIMIT" envDefault:"10"`
+	ModerationRateWindowSeconds int      `env:"MODERATION_RATE_WINDOW_SECONDS" envDefault:"60"`
+
+	ReportHideThreshold int `env:"REPORT_HIDE_THRESHOLD" envDefault:"5"`
 }
 
 func GetConfigFromEnv() *EnvConfig {
@@ -0,0 +1,71 @@
+package moderation
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// minStemLength keeps short words intact, otherwise stripping an ending leaves almost nothing to compare.
+const minStemLength = 3
+
+var wordRegexp = regexp.MustCompile(`[\p{L}]+`)
+
+// endings are the most common russian and english inflections, the longest first.
+var endings = []string{
+	"иями", "ями", "ами", "ого", "его", "ому", "ему", "ыми", "ими", "ией", "ием", "иях", "ах", "ях", "ов", "ев", "ей", "ам", "ям",
+	"ом", "ем", "ой", "ий", "ый", "ая", "яя", "ое", "ее", "ые", "ие", "ую", "юю", "ть", "ешь", "ет", "ут", "ют", "ит", "ат", "ят",
+	"а", "я", "ы", "и", "у", "ю", "е", "о", "ь",
+	"ings", "ing", "ers", "er", "ed", "es", "ly", "s",
+}
+
+type BannedWordsCheck struct {
+	bannedStems map[string]struct{}
+}
+
+func NewBannedWordsCheck(bannedWords []string) *BannedWordsCheck {
+	bannedStems := make(map[string]struct{}, len(bannedWords))
+
+	for _, bannedWord := range bannedWords {
+		if bannedWord = strings.TrimSpace(bannedWord); bannedWord != "" {
+			bannedStems[stem(bannedWord)] = struct{}{}
+		}
+	}
+
+	return &BannedWordsCheck{
+		bannedStems: bannedStems,
+	}
+}
+
+func (c *BannedWordsCheck) Check(_ context.Context, content Content) (Result, error) {
+	if len(c.bannedStems) == 0 {
+		return Result{Verdict: VerdictAllow}, nil
+	}
+
+	for _, word := range wordRegexp.FindAllString(content.Text, -1) {
+		if _, ok := c.bannedStems[stem(word)]; ok {
+			return Result{Verdict: VerdictReject, Reason: "banned words"}, nil
+		}
+	}
+
+	return Result{Verdict: VerdictAllow}, nil
+}
+
+// stem cuts the word down to its stem, so different forms of a banned word match it.
+func stem(word string) string {
+	word = strings.ReplaceAll(strings.ToLower(word), "ё", "е")
+
+	for _, ending := range endings {
+		if !strings.HasSuffix(word, ending) {
+			continue
+		}
+
+		stemmed := strings.TrimSuffix(word, ending)
+		if utf8.RuneCountInString(stemmed) >= minStemLength {
+			return stemmed
+		}
+	}
+
+	return word
+}
@@ -0,0 +1,27 @@
+package moderation
+
+import (
+	"context"
+	"regexp"
+)
+
+var linkRegexp = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// LinksCheck holds for review the content with more links than allowed, it is a common sign of spam.
+type LinksCheck struct {
+	maxLinks int
+}
+
+func NewLinksCheck(maxLinks int) *LinksCheck {
+	return &LinksCheck{
+		maxLinks: maxLinks,
+	}
+}
+
+func (c *LinksCheck) Check(_ context.Context, content Content) (Result, error) {
+	if len(linkRegexp.FindAllStringIndex(content.Text, c.maxLinks+1)) > c.maxLinks {
+		return Result{Verdict: VerdictHold, Reason: "too many links"}, nil
+	}
+
+	return Result{Verdict: VerdictAllow}, nil
+}
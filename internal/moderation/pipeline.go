@@ -0,0 +1,104 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type Verdict int
+
+const (
+	VerdictAllow Verdict = iota
+	VerdictHold
+	VerdictReject
+)
+
+const (
+	ContentKindPost    = "post"
+	ContentKindComment = "comment"
+	ContentKindMessage = "message"
+)
+
+type Content struct {
+	Kind     string
+	AuthorID string
+	Text     string
+	// Edit marks the new text of already stored content, edits are not rate limited
+	Edit bool
+}
+
+type Result struct {
+	Verdict Verdict
+	Reason  string
+}
+
+type Check interface {
+	Check(ctx context.Context, content Content) (Result, error)
+}
+
+// Releaser is implemented by the checks that reserve a share of a limit for the content they pass.
+// The share is given back when the content is not stored after all.
+type Releaser interface {
+	Release(ctx context.Context, content Content) error
+}
+
+// Pipeline runs the content through the checks in order. The first rejection wins,
+// a hold is reported only when no later check rejects the content.
+type Pipeline struct {
+	checks []Check
+}
+
+func NewPipeline(checks ...Check) *Pipeline {
+	return &Pipeline{
+		checks: checks,
+	}
+}
+
+func (p *Pipeline) Run(ctx context.Context, content Content) (Result, error) {
+	result := Result{Verdict: VerdictAllow}
+
+	// the checks passed so far, their reservations are given back when a later check rejects the content
+	var passed []Check
+
+	for _, check := range p.checks {
+		checkResult, err := check.Check(ctx, content)
+		if err != nil {
+			return Result{}, p.release(ctx, content, passed, fmt.Errorf("moderation check failed: %w", err))
+		}
+
+		switch checkResult.Verdict {
+		case VerdictReject:
+			return checkResult, p.release(ctx, content, passed, nil)
+		case VerdictHold:
+			if result.Verdict == VerdictAllow {
+				result = checkResult
+			}
+		case VerdictAllow:
+		}
+
+		passed = append(passed, check)
+	}
+
+	return result, nil
+}
+
+// Release gives back what the checks have reserved for the content that passed moderation but has not been stored.
+func (p *Pipeline) Release(ctx context.Context, content Content) error {
+	return p.release(ctx, content, p.checks, nil)
+}
+
+func (p *Pipeline) release(ctx context.Context, content Content, checks []Check, err error) error {
+	for _, check := range checks {
+		releaser, ok := check.(Releaser)
+		if !ok {
+			continue
+		}
+
+		if releaseErr := releaser.Release(ctx, content); releaseErr != nil {
+			err = errors.Join(err, fmt.Errorf("moderation release failed: %w", releaseErr))
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,89 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"myfacebook/internal/rdb"
+)
+
+const rateCachePrefix = "moderation:rate:"
+
+// reserveRateScript counts the content when the author is within the limit and sets the window expiration on the first
+// count only, so a key never stays without a TTL. KEYS[1] is the counter, ARGV are the window in milliseconds and the limit.
+// It returns 1 when the content is counted and 0 when the limit is reached.
+var reserveRateScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if redis.call("PTTL", KEYS[1]) == -1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+if count > tonumber(ARGV[2]) then
+	redis.call("DECR", KEYS[1])
+	return 0
+end
+return 1
+`)
+
+// releaseRateScript uncounts the content, the counter of an expired window is left alone. KEYS[1] is the counter.
+var releaseRateScript = redis.NewScript(`
+if tonumber(redis.call("GET", KEYS[1]) or "0") > 0 then
+	return redis.call("DECR", KEYS[1])
+end
+return 0
+`)
+
+// RateCheck rejects the content when the author has posted more than the limit within the window.
+// The content is counted as soon as it passes the check, so concurrent requests cannot exceed the limit together.
+type RateCheck struct {
+	redisDB *rdb.RedisDB
+	limit   int64
+	window  time.Duration
+}
+
+func NewRateCheck(redisDB *rdb.RedisDB, limit int64, window time.Duration) *RateCheck {
+	return &RateCheck{
+		redisDB: redisDB,
+		limit:   limit,
+		window:  window,
+	}
+}
+
+// Check counts the content of the author, the window starts with the first content of the author
+// and is not prolonged by the next ones.
+func (c *RateCheck) Check(ctx context.Context, content Content) (Result, error) {
+	if content.Edit {
+		return Result{Verdict: VerdictAllow}, nil
+	}
+
+	reserved, err := reserveRateScript.Run(ctx, c.redisDB.GetClient(), []string{c.key(content)},
+		c.window.Milliseconds(), c.limit).Int()
+	if err != nil {
+		return Result{}, fmt.Errorf("moderation failed to count %s rate of user %q: %w", content.Kind, content.AuthorID, err)
+	}
+
+	if reserved == 0 {
+		return Result{Verdict: VerdictReject, Reason: "posting too often"}, nil
+	}
+
+	return Result{Verdict: VerdictAllow}, nil
+}
+
+// Release uncounts the content that passed the check but has not been stored.
+func (c *RateCheck) Release(ctx context.Context, content Content) error {
+	if content.Edit {
+		return nil
+	}
+
+	err := releaseRateScript.Run(ctx, c.redisDB.GetClient(), []string{c.key(content)}).Err()
+	if err != nil {
+		return fmt.Errorf("moderation failed to uncount %s rate of user %q: %w", content.Kind, content.AuthorID, err)
+	}
+
+	return nil
+}
+
+func (c *RateCheck) key(content Content) string {
+	return rateCachePrefix + content.Kind + ":" + content.AuthorID
+}
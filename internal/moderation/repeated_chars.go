@@ -0,0 +1,45 @@
+package moderation
+
+import (
+	"context"
+	"unicode"
+)
+
+// RepeatedCharsCheck rejects the content with long runs of the same character, like "!!!!!!!!!!!!".
+type RepeatedCharsCheck struct {
+	maxRepeatedChars int
+}
+
+func NewRepeatedCharsCheck(maxRepeatedChars int) *RepeatedCharsCheck {
+	return &RepeatedCharsCheck{
+		maxRepeatedChars: maxRepeatedChars,
+	}
+}
+
+func (c *RepeatedCharsCheck) Check(_ context.Context, content Content) (Result, error) {
+	var prev rune
+
+	repeated := 0
+
+	for _, r := range content.Text {
+		if unicode.IsSpace(r) {
+			prev, repeated = 0, 0
+
+			continue
+		}
+
+		r = unicode.ToLower(r)
+
+		if r == prev {
+			repeated++
+		} else {
+			prev, repeated = r, 1
+		}
+
+		if repeated > c.maxRepeatedChars {
+			return Result{Verdict: VerdictReject, Reason: "repeated characters"}, nil
+		}
+	}
+
+	return Result{Verdict: VerdictAllow}, nil
+}
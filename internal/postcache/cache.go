@@ -0,0 +1,220 @@
+package postcache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"myfacebook/internal/rdb"
+	"myfacebook/internal/repository"
+)
+
+const (
+	postCachePrefix         = "postpayload:post_"
+	postCountersCachePrefix = "postcounters:post_"
+	cacheTTL                = 7 * 24 * time.Hour
+	// countersCacheTTL bounds how long counters may stay wrong when a change misses the invalidation.
+	countersCacheTTL = time.Hour
+)
+
+var (
+	postFields    = []string{"author_id", "text", "original_post_id", "visibility", "status", "created_at"}
+	counterFields = []string{"comments", "shares"}
+)
+
+// Counters are the post counts shown in the feeds.
+type Counters struct {
+	Comments int
+	Shares   int
+}
+
+// Cache keeps published posts payloads for the feeds, so feed reads do not go to the db.
+type Cache struct {
+	redisDB *rdb.RedisDB
+}
+
+func New(redisDB *rdb.RedisDB) *Cache {
+	return &Cache{
+		redisDB: redisDB,
+	}
+}
+
+func (c *Cache) SetPosts(ctx context.Context, posts []repository.Post) error {
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, post := range posts {
+			key := postCachePrefix + post.ID
+
+			pipe.HSet(ctx, key,
+				"author_id", post.AuthorID,
+				"text", post.Text,
+				"original_post_id", post.OriginalPostID,
+				"visibility", post.Visibility,
+				"status", post.Status,
+				"created_at", post.CreatedAt.UnixMicro(),
+			)
+			pipe.Expire(ctx, key, cacheTTL)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postcache failed to set posts: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Cache) DeletePost(ctx context.Context, postID string) error {
+	_, err := c.redisDB.GetClient().Del(ctx, postCachePrefix+postID).Result()
+	if err != nil {
+		return fmt.Errorf("postcache failed to delete post %q: %w", postID, err)
+	}
+
+	return nil
+}
+
+// GetPosts returns cached posts and the ids of posts missing in the cache.
+func (c *Cache) GetPosts(ctx context.Context, postIDs []string) (map[string]repository.Post, []string, error) {
+	cmds := make([]*redis.SliceCmd, 0, len(postIDs))
+
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, postID := range postIDs {
+			cmds = append(cmds, pipe.HMGet(ctx, postCachePrefix+postID, postFields...))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("postcache failed to get posts: %w", err)
+	}
+
+	posts := make(map[string]repository.Post, len(postIDs))
+
+	var missedPostsIDs []string
+
+	for i, cmd := range cmds {
+		values := cmd.Val()
+
+		// a hash missing any field, e.g. expired between the commands, is reloaded as a whole
+		complete := len(values) == len(postFields)
+		for _, value := range values {
+			if value == nil {
+				complete = false
+			}
+		}
+
+		if !complete {
+			missedPostsIDs = append(missedPostsIDs, postIDs[i])
+
+			continue
+		}
+
+		createdAtMicro, err := strconv.ParseInt(values[5].(string), 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("postcache failed to parse created at %q: %w", values[5], err)
+		}
+
+		posts[postIDs[i]] = repository.Post{
+			ID:             postIDs[i],
+			AuthorID:       values[0].(string),
+			Text:           values[1].(string),
+			OriginalPostID: values[2].(string),
+			Visibility:     values[3].(string),
+			Status:         values[4].(string),
+			CreatedAt:      time.UnixMicro(createdAtMicro).UTC(),
+		}
+	}
+
+	return posts, missedPostsIDs, nil
+}
+
+// SetCounters caches the counters of the posts, the posts missing in counters are cached with zero counts.
+func (c *Cache) SetCounters(ctx context.Context, postIDs []string, counters map[string]Counters) error {
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, postID := range postIDs {
+			key := postCountersCachePrefix + postID
+
+			pipe.HSet(ctx, key,
+				"comments", counters[postID].Comments,
+				"shares", counters[postID].Shares,
+			)
+			pipe.Expire(ctx, key, countersCacheTTL)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postcache failed to set counters: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCounters drops the cached counters of the posts, they are reloaded from the db on the next read.
+func (c *Cache) DeleteCounters(ctx context.Context, postIDs ...string) error {
+	keys := make([]string, 0, len(postIDs))
+	for _, postID := range postIDs {
+		keys = append(keys, postCountersCachePrefix+postID)
+	}
+
+	_, err := c.redisDB.GetClient().Del(ctx, keys...).Result()
+	if err != nil {
+		return fmt.Errorf("postcache failed to delete counters: %w", err)
+	}
+
+	return nil
+}
+
+// GetCounters returns cached counters and the ids of posts missing in the cache.
+func (c *Cache) GetCounters(ctx context.Context, postIDs []string) (map[string]Counters, []string, error) {
+	cmds := make([]*redis.SliceCmd, 0, len(postIDs))
+
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, postID := range postIDs {
+			cmds = append(cmds, pipe.HMGet(ctx, postCountersCachePrefix+postID, counterFields...))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("postcache failed to get counters: %w", err)
+	}
+
+	counters := make(map[string]Counters, len(postIDs))
+
+	var missedPostsIDs []string
+
+	for i, cmd := range cmds {
+		values := cmd.Val()
+
+		counts := make([]int, 0, len(counterFields))
+
+		for _, value := range values {
+			if value == nil {
+				break
+			}
+
+			count, err := strconv.Atoi(value.(string))
+			if err != nil {
+				return nil, nil, fmt.Errorf("postcache failed to parse counter %q: %w", value, err)
+			}
+
+			counts = append(counts, count)
+		}
+
+		if len(counts) != len(counterFields) {
+			missedPostsIDs = append(missedPostsIDs, postIDs[i])
+
+			continue
+		}
+
+		counters[postIDs[i]] = Counters{
+			Comments: counts[0],
+			Shares:   counts[1],
+		}
+	}
+
+	return counters, missedPostsIDs, nil
+}
@@ -0,0 +1,104 @@
+package postfanoutservice
+
+import (
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the fan-out latency histogram, the last bucket has no bound.
+var latencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+type LatencyBucket struct {
+	// UpperBound is zero for the last bucket
+	UpperBound time.Duration
+	Count      int64
+}
+
+// Metrics describe the messages processed since the service start.
+type Metrics struct {
+	MessagesCount  int64
+	FailuresCount  int64
+	FeedsCount     int64
+	LatencyTotal   time.Duration
+	LatencyMax     time.Duration
+	LatencyBuckets []LatencyBucket
+}
+
+type metrics struct {
+	mu sync.Mutex
+
+	messagesCount int64
+	failuresCount int64
+	feedsCount    int64
+	latencyTotal  time.Duration
+	latencyMax    time.Duration
+	latencyCounts []int64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		latencyCounts: make([]int64, len(latencyBuckets)+1),
+	}
+}
+
+func (m *metrics) record(latency time.Duration, feedsCount int, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messagesCount++
+	m.feedsCount += int64(feedsCount)
+
+	if failed {
+		m.failuresCount++
+	}
+
+	m.latencyTotal += latency
+	m.latencyMax = max(m.latencyMax, latency)
+
+	bucket := len(latencyBuckets)
+
+	for i, upperBound := range latencyBuckets {
+		if latency <= upperBound {
+			bucket = i
+
+			break
+		}
+	}
+
+	m.latencyCounts[bucket]++
+}
+
+func (m *metrics) snapshot() Metrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buckets := make([]LatencyBucket, 0, len(m.latencyCounts))
+
+	for i, count := range m.latencyCounts {
+		var upperBound time.Duration
+		if i < len(latencyBuckets) {
+			upperBound = latencyBuckets[i]
+		}
+
+		buckets = append(buckets, LatencyBucket{
+			UpperBound: upperBound,
+			Count:      count,
+		})
+	}
+
+	return Metrics{
+		MessagesCount:  m.messagesCount,
+		FailuresCount:  m.failuresCount,
+		FeedsCount:     m.feedsCount,
+		LatencyTotal:   m.latencyTotal,
+		LatencyMax:     m.latencyMax,
+		LatencyBuckets: buckets,
+	}
+}
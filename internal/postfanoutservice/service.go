@@ -5,52 +5,97 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
+	amqp "github.com/rabbitmq/amqp091-go"
 	"myfacebook/internal/config"
+	"myfacebook/internal/postcache"
 	"myfacebook/internal/postfeedcache"
+	"myfacebook/internal/postvisibility"
 	"myfacebook/internal/repository"
 	"myfacebook/internal/rmq"
+	"myfacebook/internal/userstats"
 )
 
 type postFeedRMQMessage struct {
-	Operation string `json:"operation,omitempty"`
-	PostID    string `json:"post_id"`
-	PostText  string `json:"post_text"`
-	AuthorID  string `json:"author_id"`
+	Operation      string     `json:"operation,omitempty"`
+	PostID         string     `json:"post_id"`
+	PostText       string     `json:"post_text"`
+	AuthorID       string     `json:"author_id"`
+	OriginalPostID string     `json:"original_post_id,omitempty"`
+	Visibility     string     `json:"visibility,omitempty"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
 }
 
 type Service struct {
-	rmq            *rmq.RMQ
-	userRepository repository.UserRepository
-	postFeedCache  *postfeedcache.Cache
-	envConfig      *config.EnvConfig
+	rmq                   *rmq.RMQ
+	userRepository        repository.UserRepository
+	postFeedCache         *postfeedcache.Cache
+	postCache             *postcache.Cache
+	postVisibilityChecker *postvisibility.Checker
+	userStatsCounter      *userstats.Counter
+	envConfig             *config.EnvConfig
+
+	metrics *metrics
 
 	done chan struct{}
 	wg   *sync.WaitGroup
 }
 
-var errInvalidPostOperation = errors.New("invalid post operation")
+const (
+	// failedPostFeedQueueName keeps the post messages the fan-out gave up on, for inspection and manual replay.
+	failedPostFeedQueueName = "/post/feed/failed"
+	fanoutRetryBackoff      = 100 * time.Millisecond
+	fanoutMaxRetryBackoff   = 10 * time.Second
+)
 
-func New(rmq *rmq.RMQ, userRepository repository.UserRepository, postFeedCache *postfeedcache.Cache, envConfig *config.EnvConfig) *Service {
+var (
+	errInvalidPostMessage   = errors.New("invalid post message")
+	errInvalidPostOperation = fmt.Errorf("%w: invalid post operation", errInvalidPostMessage)
+	errFanoutStopped        = errors.New("post fanout service is stopped")
+)
+
+func New(rmq *rmq.RMQ, userRepository repository.UserRepository, postFeedCache *postfeedcache.Cache,
+	postCache *postcache.Cache, postVisibilityChecker *postvisibility.Checker, userStatsCounter *userstats.Counter,
+	envConfig *config.EnvConfig,
+) *Service {
 	return &Service{
-		rmq:            rmq,
-		userRepository: userRepository,
-		postFeedCache:  postFeedCache,
-		envConfig:      envConfig,
-		done:           make(chan struct{}),
-		wg:             &sync.WaitGroup{},
+		rmq:                   rmq,
+		userRepository:        userRepository,
+		postFeedCache:         postFeedCache,
+		postCache:             postCache,
+		postVisibilityChecker: postVisibilityChecker,
+		userStatsCounter:      userStatsCounter,
+		envConfig:             envConfig,
+		metrics:               newMetrics(),
+		done:                  make(chan struct{}),
+		wg:                    &sync.WaitGroup{},
 	}
 }
 
+// Start consumes the post messages with a pool of workers. The messages of the same author always go to the same worker,
+// so the author posts are fanned out in order.
 func (s *Service) Start(ctx context.Context) error {
-	msgs, err := s.rmq.Consume(ctx, "/post/feed")
+	msgs, err := s.rmq.ConsumeWithPrefetch(ctx, "/post/feed", s.envConfig.PostFanoutPrefetch)
 	if err != nil {
 		return fmt.Errorf("postfanoutservice failed to consume rmq messages: %w", err)
 	}
 
+	workers := make([]chan amqp.Delivery, max(s.envConfig.PostFanoutWorkers, 1))
+
+	for i := range workers {
+		// the prefetch bounds the messages not acked yet, so a busy worker never blocks the others
+		workers[i] = make(chan amqp.Delivery, s.envConfig.PostFanoutPrefetch)
+
+		s.wg.Add(1)
+
+		go s.work(ctx, workers[i])
+	}
+
 	s.wg.Add(1)
 
 	go func() {
@@ -64,7 +109,7 @@ func (s *Service) Start(ctx context.Context) error {
 
 					select {
 					case <-time.After(1 * time.Second):
-						newMsgs, err := s.rmq.Consume(ctx, "/post/feed")
+						newMsgs, err := s.rmq.ConsumeWithPrefetch(ctx, "/post/feed", s.envConfig.PostFanoutPrefetch)
 						if err != nil {
 							slog.Error(fmt.Sprintf("postfanoutservice failed to consume rmq messages: %s", err))
 
@@ -79,19 +124,10 @@ func (s *Service) Start(ctx context.Context) error {
 					continue
 				}
 
-				err := s.processMessage(ctx, msg.Body)
-				if err != nil {
-					if err := msg.Reject(true); err != nil {
-						slog.Error(fmt.Sprintf("Error on rejecting rmq message: %s", err))
-					}
-
-					slog.Error(fmt.Sprintf("Error on processing rmq message: %s", err))
-
-					continue
-				}
-
-				if err := msg.Ack(false); err != nil {
-					slog.Error(fmt.Sprintf("Error on ack rmq message: %s", err))
+				select {
+				case workers[workerIndex(msg.Body, len(workers))] <- msg:
+				case <-s.done:
+					return
 				}
 
 			case <-s.done:
@@ -105,59 +141,313 @@ func (s *Service) Start(ctx context.Context) error {
 	return nil
 }
 
-func (s *Service) processMessage(ctx context.Context, msg []byte) error {
+// workerIndex picks the worker by the post author, a message that cannot be parsed goes to the first worker to be moved
+// to the failed messages queue.
+func workerIndex(msg []byte, workersCount int) int {
+	var postMsg postFeedRMQMessage
+
+	if err := json.Unmarshal(msg, &postMsg); err != nil {
+		return 0
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(postMsg.AuthorID))
+
+	return int(hash.Sum32() % uint32(workersCount))
+}
+
+// work fans out the messages of its authors one by one. A failed message is retried by the worker itself with a backoff,
+// the later messages of the author wait for it, so they are never applied before it. A message still failing after
+// PostFanoutMaxAttempts attempts, or one that cannot be parsed, is moved to the failed messages queue.
+func (s *Service) work(ctx context.Context, msgs <-chan amqp.Delivery) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case msg := <-msgs:
+			startedAt := time.Now()
+
+			feedsCount, err := s.processWithRetries(ctx, msg.Body)
+			if errors.Is(err, errFanoutStopped) {
+				// the message is not acked, so it is redelivered after the restart
+				return
+			}
+
+			latency := time.Since(startedAt)
+
+			s.metrics.record(latency, feedsCount, err != nil)
+
+			if err != nil {
+				slog.Error(fmt.Sprintf("Error on processing rmq message, moving it to %s: %s", failedPostFeedQueueName, err))
+
+				if err := s.moveToFailed(ctx, msg.Body); err != nil {
+					return
+				}
+			} else {
+				slog.Debug(fmt.Sprintf("Post fanned out to %d feeds in %s", feedsCount, latency))
+			}
+
+			if err := msg.Ack(false); err != nil {
+				slog.Error(fmt.Sprintf("Error on ack rmq message: %s", err))
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// processWithRetries processes the message until it succeeds or the attempts run out, the last error is returned then.
+func (s *Service) processWithRetries(ctx context.Context, msg []byte) (int, error) {
+	backoff := fanoutRetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		feedsCount, err := s.processMessage(ctx, msg)
+		if err == nil {
+			return feedsCount, nil
+		}
+
+		if errors.Is(err, errInvalidPostMessage) || attempt >= max(s.envConfig.PostFanoutMaxAttempts, 1) {
+			return feedsCount, err
+		}
+
+		slog.Error(fmt.Sprintf("Error on processing rmq message, attempt %d, retrying in %s: %s", attempt, backoff, err))
+
+		if err := s.wait(backoff); err != nil {
+			return 0, err
+		}
+
+		backoff = min(backoff*2, fanoutMaxRetryBackoff)
+	}
+}
+
+// moveToFailed publishes the message to the failed messages queue, retrying until it succeeds,
+// so the message is acked only once it is kept there.
+func (s *Service) moveToFailed(ctx context.Context, msg []byte) error {
+	backoff := fanoutRetryBackoff
+
+	for {
+		err := s.rmq.Publish(ctx, "", failedPostFeedQueueName, msg)
+		if err == nil {
+			return nil
+		}
+
+		slog.Error(fmt.Sprintf("Error on publishing rmq message to %s, retrying in %s: %s", failedPostFeedQueueName, backoff, err))
+
+		if err := s.wait(backoff); err != nil {
+			return err
+		}
+
+		backoff = min(backoff*2, fanoutMaxRetryBackoff)
+	}
+}
+
+// wait sleeps for the backoff, errFanoutStopped is returned when the service is stopped in between.
+func (s *Service) wait(backoff time.Duration) error {
+	select {
+	case <-time.After(backoff):
+		return nil
+	case <-s.done:
+		return errFanoutStopped
+	}
+}
+
+// Metrics returns the fan-out metrics collected since the service start.
+func (s *Service) Metrics() Metrics {
+	return s.metrics.snapshot()
+}
+
+// processMessage returns the number of feeds the post was written to or removed from.
+func (s *Service) processMessage(ctx context.Context, msg []byte) (int, error) {
 	var postMsg postFeedRMQMessage
 
 	err := json.Unmarshal(msg, &postMsg)
 	if err != nil {
-		return fmt.Errorf("postfanoutservice failed to unmarshal rmq message: %w", err)
+		return 0, fmt.Errorf("postfanoutservice failed to unmarshal rmq message: %w: %w", errInvalidPostMessage, err)
 	}
 
-	usersCount, err := s.userRepository.GetUsersCountByFriendID(ctx, postMsg.AuthorID)
-	if err != nil {
-		return fmt.Errorf("postfanoutservice failed to count users that have the post author as friend: %w", err)
+	switch postMsg.Operation {
+	case "add", "update", "remove":
+	default:
+		return 0, fmt.Errorf("postfanoutservice failed to process message %s: %w", msg, errInvalidPostOperation)
 	}
 
-	if usersCount >= s.envConfig.PopularFriendUsersCount {
-		return nil
+	post := repository.Post{
+		ID:             postMsg.PostID,
+		Text:           postMsg.PostText,
+		AuthorID:       postMsg.AuthorID,
+		OriginalPostID: postMsg.OriginalPostID,
+		Visibility:     postMsg.Visibility,
+		Status:         repository.PostStatusPublished,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	if postMsg.CreatedAt != nil {
+		post.CreatedAt = postMsg.CreatedAt.UTC()
+	}
+
+	// a message without the post time leaves the post to be cached on the feed read
+	if postMsg.CreatedAt != nil || postMsg.Operation == "remove" {
+		err = s.cachePost(ctx, postMsg.Operation, post)
+		if err != nil {
+			return 0, err
+		}
 	}
 
-	usersIDs, err := s.userRepository.GetUsersIDsByFriendID(ctx, postMsg.AuthorID)
+	celebrity, err := s.userStatsCounter.IsCelebrity(ctx, postMsg.AuthorID)
 	if err != nil {
-		return fmt.Errorf("postfanoutservice failed to get users ids from repo: %w", err)
+		return 0, fmt.Errorf("postfanoutservice failed to check the post author is a celebrity: %w", err)
+	}
+
+	// the celebrities posts are pulled into the feeds on read
+	if celebrity {
+		return 0, nil
+	}
+
+	var mutingUsers map[string]struct{}
+
+	// the users who muted the author do not get the author posts pushed, the muted posts already in their feeds
+	// are hidden on read
+	if postMsg.Operation != "remove" {
+		mutingUsers, err = s.getMutingUsers(ctx, postMsg.AuthorID)
+		if err != nil {
+			return 0, err
+		}
 	}
 
+	var feedsCount int
+
+	chunkSize := max(s.envConfig.PostFanoutChunkSize, 1)
+
+	// the followers are read in chunks, so a large audience is not loaded at once
+	for afterUserID := ""; ; {
+		usersIDs, err := s.userRepository.GetUsersIDsByFriendIDAfter(ctx, postMsg.AuthorID, afterUserID, chunkSize)
+		if err != nil {
+			return feedsCount, fmt.Errorf("postfanoutservice failed to get users ids from repo: %w", err)
+		}
+
+		if len(usersIDs) == 0 {
+			return feedsCount, nil
+		}
+
+		afterUserID = usersIDs[len(usersIDs)-1]
+		lastChunk := len(usersIDs) < chunkSize
+
+		usersIDs = slices.DeleteFunc(usersIDs, func(userID string) bool {
+			_, ok := mutingUsers[userID]
+
+			return ok
+		})
+
+		chunkFeedsCount, err := s.fanoutChunk(ctx, postMsg, post, usersIDs)
+		if err != nil {
+			return feedsCount, err
+		}
+
+		feedsCount += chunkFeedsCount
+
+		if lastChunk {
+			return feedsCount, nil
+		}
+	}
+}
+
+// fanoutChunk applies the post operation to the feeds of the users, the feeds are written in one round trip.
+func (s *Service) fanoutChunk(ctx context.Context, postMsg postFeedRMQMessage, post repository.Post, usersIDs []string) (int, error) {
 	switch postMsg.Operation {
 	case "add":
+		usersIDs, err := s.postVisibilityChecker.FilterAudience(ctx, post, usersIDs)
+		if err != nil {
+			return 0, fmt.Errorf("postfanoutservice failed to filter post audience: %w", err)
+		}
+
+		writtenCount, err := s.postFeedCache.AddPostIDToFeeds(ctx, usersIDs, postMsg.PostID, post.CreatedAt)
+		if err != nil {
+			return 0, fmt.Errorf("postfanoutservice failed to push post to post feed cache: %w", err)
+		}
+
 		postFeedRMQMsg, err := json.Marshal(postFeedRMQMessage{
 			PostID:   postMsg.PostID,
 			PostText: postMsg.PostText,
 			AuthorID: postMsg.AuthorID,
 		})
 		if err != nil {
-			return fmt.Errorf("postfanoutservice failed to marshal rmq message: %w", err)
+			return 0, fmt.Errorf("postfanoutservice failed to marshal rmq message: %w", err)
 		}
 
-		for _, userID := range usersIDs {
-			err := s.postFeedCache.AddPostID(ctx, userID, postMsg.PostID)
-			if err != nil {
-				return fmt.Errorf("postfanoutservice failed to push post to post feed cache: %w", err)
-			}
+		// the consumers bind a queue per user, so the message is still routed by the user id
+		err = s.rmq.PublishMany(ctx, "/post/feed/posted", usersIDs, postFeedRMQMsg)
+		if err != nil {
+			return 0, fmt.Errorf("postfanoutservice failed to publish rmq messages: %w", err)
+		}
 
-			err = s.rmq.Publish(ctx, "/post/feed/posted", userID, postFeedRMQMsg)
-			if err != nil {
-				return fmt.Errorf("postfanoutservice failed to publish rmq message: %w", err)
-			}
+		return writtenCount, nil
+	case "update":
+		allowedUsersIDs, err := s.postVisibilityChecker.FilterAudience(ctx, post, usersIDs)
+		if err != nil {
+			return 0, fmt.Errorf("postfanoutservice failed to filter post audience: %w", err)
 		}
-	case "remove":
+
+		allowed := make(map[string]struct{}, len(allowedUsersIDs))
+		for _, userID := range allowedUsersIDs {
+			allowed[userID] = struct{}{}
+		}
+
+		var deniedUsersIDs []string
+
 		for _, userID := range usersIDs {
-			err := s.postFeedCache.RemovePostID(ctx, userID, postMsg.PostID)
-			if err != nil {
-				return fmt.Errorf("postfanoutservice failed to remove post from post feed cache: %w", err)
+			if _, ok := allowed[userID]; !ok {
+				deniedUsersIDs = append(deniedUsersIDs, userID)
 			}
 		}
+
+		err = s.postFeedCache.RemovePostIDFromFeeds(ctx, deniedUsersIDs, postMsg.PostID)
+		if err != nil {
+			return 0, fmt.Errorf("postfanoutservice failed to remove post from post feed cache: %w", err)
+		}
+
+		_, err = s.postFeedCache.AddPostIDToFeeds(ctx, allowedUsersIDs, postMsg.PostID, post.CreatedAt)
+		if err != nil {
+			return 0, fmt.Errorf("postfanoutservice failed to push post to post feed cache: %w", err)
+		}
+
+		return len(usersIDs), nil
 	default:
-		return fmt.Errorf("postfanoutservice failed to process message %s: %w", msg, errInvalidPostOperation)
+		err := s.postFeedCache.RemovePostIDFromFeeds(ctx, usersIDs, postMsg.PostID)
+		if err != nil {
+			return 0, fmt.Errorf("postfanoutservice failed to remove post from post feed cache: %w", err)
+		}
+
+		return len(usersIDs), nil
+	}
+}
+
+func (s *Service) getMutingUsers(ctx context.Context, authorID string) (map[string]struct{}, error) {
+	mutingUsersIDs, err := s.userRepository.GetUsersIDsMutingFriendID(ctx, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("postfanoutservice failed to get users muting the author: %w", err)
+	}
+
+	muting := make(map[string]struct{}, len(mutingUsersIDs))
+	for _, userID := range mutingUsersIDs {
+		muting[userID] = struct{}{}
+	}
+
+	return muting, nil
+}
+
+// cachePost keeps the cached post payload in line with the post, the payload is cached for the popular authors too.
+func (s *Service) cachePost(ctx context.Context, operation string, post repository.Post) error {
+	switch operation {
+	case "add", "update":
+		err := s.postCache.SetPosts(ctx, []repository.Post{post})
+		if err != nil {
+			return fmt.Errorf("postfanoutservice failed to cache post: %w", err)
+		}
+	case "remove":
+		err := s.postCache.DeletePost(ctx, post.ID)
+		if err != nil {
+			return fmt.Errorf("postfanoutservice failed to delete post from cache: %w", err)
+		}
 	}
 
 	return nil
@@ -0,0 +1,180 @@
+package postfeedbuilder
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"myfacebook/internal/postcache"
+	"myfacebook/internal/postfeedcache"
+	"myfacebook/internal/postvisibility"
+	"myfacebook/internal/repository"
+)
+
+const (
+	rebuildLockTTL     = 30 * time.Second
+	rebuildWaitTimeout = 3 * time.Second
+	rebuildWaitPeriod  = 50 * time.Millisecond
+)
+
+// Builder restores the user feed from the db when it is lost from the cache, e.g. after Redis is flushed.
+type Builder struct {
+	postRepository        repository.PostRepository
+	postFeedCache         *postfeedcache.Cache
+	postCache             *postcache.Cache
+	postVisibilityChecker *postvisibility.Checker
+}
+
+func New(postRepository repository.PostRepository, postFeedCache *postfeedcache.Cache, postCache *postcache.Cache,
+	postVisibilityChecker *postvisibility.Checker,
+) *Builder {
+	return &Builder{
+		postRepository:        postRepository,
+		postFeedCache:         postFeedCache,
+		postCache:             postCache,
+		postVisibilityChecker: postVisibilityChecker,
+	}
+}
+
+// EnsureFeed rebuilds a cold feed of the user. Only one rebuild of the same feed runs at a time,
+// the others wait for it a little and go on with whatever the feed has.
+func (b *Builder) EnsureFeed(ctx context.Context, userID string) error {
+	built, err := b.postFeedCache.IsBuilt(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("postfeedbuilder failed to check feed: %w", err)
+	}
+
+	if built {
+		return nil
+	}
+
+	ownerUUIDv4, err := uuid.NewV4()
+	if err != nil {
+		return fmt.Errorf("postfeedbuilder failed to generate lock owner uuid: %w", err)
+	}
+
+	owner := ownerUUIDv4.String()
+
+	locked, err := b.postFeedCache.AcquireRebuildLock(ctx, userID, owner, rebuildLockTTL)
+	if err != nil {
+		return fmt.Errorf("postfeedbuilder, %w", err)
+	}
+
+	if !locked {
+		return b.waitBuilt(ctx, userID)
+	}
+
+	defer func() {
+		if err := b.postFeedCache.ReleaseRebuildLock(ctx, userID, owner); err != nil {
+			slog.Error(fmt.Sprintf("Error on releasing post feed rebuild lock: %s", err))
+		}
+	}()
+
+	// the feed might have been built between the check and the lock
+	built, err = b.postFeedCache.IsBuilt(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("postfeedbuilder failed to check feed: %w", err)
+	}
+
+	if built {
+		return nil
+	}
+
+	return b.Rebuild(ctx, userID, time.Time{})
+}
+
+// FeedPosts returns the posts the user feed is built of: the last posts of the user friends visible to the user,
+// created not before since unless it is zero.
+func (b *Builder) FeedPosts(ctx context.Context, userID string, since time.Time) ([]repository.Post, error) {
+	posts, err := b.postRepository.GetFriendsPosts(ctx, userID, since, b.postFeedCache.MaxFeedLen())
+	if err != nil {
+		return nil, fmt.Errorf("postfeedbuilder failed to get friends posts: %w", err)
+	}
+
+	posts, err = b.postVisibilityChecker.FilterVisible(ctx, userID, posts)
+	if err != nil {
+		return nil, fmt.Errorf("postfeedbuilder failed to filter visible posts: %w", err)
+	}
+
+	return posts, nil
+}
+
+// Rebuild fills the user feed with the feed posts.
+// Posts already in the feed stay there, so it is safe to rebuild a feed that is not lost.
+// The feed is marked as being built before the db is read, so the posts fanned out meanwhile get into it too.
+func (b *Builder) Rebuild(ctx context.Context, userID string, since time.Time) error {
+	rebuiltAt := time.Now()
+
+	err := b.postFeedCache.SetBuilding(ctx, userID, rebuildLockTTL)
+	if err != nil {
+		return fmt.Errorf("postfeedbuilder, %w", err)
+	}
+
+	posts, err := b.FeedPosts(ctx, userID, since)
+	if err != nil {
+		return err
+	}
+
+	createdAt := make(map[string]time.Time, len(posts))
+	for _, post := range posts {
+		createdAt[post.ID] = post.CreatedAt
+	}
+
+	err = b.postFeedCache.AddPostsIDs(ctx, userID, createdAt)
+	if err != nil {
+		return fmt.Errorf("postfeedbuilder, %w", err)
+	}
+
+	if len(posts) > 0 {
+		err = b.postCache.SetPosts(ctx, posts)
+		if err != nil {
+			return fmt.Errorf("postfeedbuilder, %w", err)
+		}
+	}
+
+	// popular friends posts are in the feed already, there is nothing to pull for them
+	err = b.postFeedCache.SetLastRetrievedAt(ctx, userID, rebuiltAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("postfeedbuilder, %w", err)
+	}
+
+	err = b.postFeedCache.SetBuilt(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("postfeedbuilder, %w", err)
+	}
+
+	err = b.postFeedCache.DeleteBuilding(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("postfeedbuilder, %w", err)
+	}
+
+	return nil
+}
+
+func (b *Builder) waitBuilt(ctx context.Context, userID string) error {
+	timeout := time.NewTimer(rebuildWaitTimeout)
+	defer timeout.Stop()
+
+	ticker := time.NewTicker(rebuildWaitPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			built, err := b.postFeedCache.IsBuilt(ctx, userID)
+			if err != nil {
+				return fmt.Errorf("postfeedbuilder failed to check feed: %w", err)
+			}
+
+			if built {
+				return nil
+			}
+		case <-timeout.C:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("postfeedbuilder stopped waiting for feed rebuild: %w", ctx.Err())
+		}
+	}
+}
@@ -4,52 +4,219 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"myfacebook/internal/rdb"
 )
 
 const (
-	maxListLen                         = 1000
 	postFeedCachePrefix                = "postfeed:user_"
 	postFeedLastRetrievedAtCachePrefix = "postfeed:last_retrieved_at:user_"
+	postFeedBuiltCachePrefix           = "postfeed:built:user_"
+	postFeedBuildingCachePrefix        = "postfeed:building:user_"
+	postFeedRebuildLockCachePrefix     = "postfeed:rebuild_lock:user_"
+	postFeedRebuildProgressCacheKey    = "postfeed:rebuild_progress"
+	postFeedRebuildFailedCacheKey      = "postfeed:rebuild_failed"
 )
 
+// addToLiveFeedsScript puts the post into the feeds whose built mark has not expired or which are being rebuilt,
+// the other feeds are rebuilt on read. KEYS go in triples of the built mark, the building mark and the feed keys,
+// ARGV are the score, the post id and the feed max length. A feed created by the script gets the built mark expiration.
+var addToLiveFeedsScript = redis.NewScript(`
+local written = 0
+for i = 1, #KEYS, 3 do
+	local ttl = redis.call("PTTL", KEYS[i])
+	if ttl ~= -2 or redis.call("EXISTS", KEYS[i + 1]) == 1 then
+		redis.call("ZADD", KEYS[i + 2], ARGV[1], ARGV[2])
+		redis.call("ZREMRANGEBYRANK", KEYS[i + 2], 0, -tonumber(ARGV[3]) - 1)
+		if ttl > 0 and redis.call("PTTL", KEYS[i + 2]) == -1 then
+			redis.call("PEXPIRE", KEYS[i + 2], ttl)
+		end
+		written = written + 1
+	end
+end
+return written
+`)
+
+// releaseLockScript deletes the lock only when it is still held by the same owner,
+// a lock expired and taken by another one is left alone.
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Cache keeps user feeds as sorted sets of posts ids scored by the post creation time in milliseconds.
+// A feed not read for feedTTL expires along with its built mark and is rebuilt on the next read, zero feedTTL keeps
+// the feeds forever.
 type Cache struct {
-	redisDB *rdb.RedisDB
+	redisDB    *rdb.RedisDB
+	maxFeedLen int
+	feedTTL    time.Duration
 }
 
-func New(redisDB *rdb.RedisDB) *Cache {
+func New(redisDB *rdb.RedisDB, maxFeedLen int, feedTTL time.Duration) *Cache {
 	return &Cache{
-		redisDB: redisDB,
+		redisDB:    redisDB,
+		maxFeedLen: maxFeedLen,
+		feedTTL:    feedTTL,
+	}
+}
+
+// MaxFeedLen is the number of the newest posts kept in a feed.
+func (c *Cache) MaxFeedLen() int {
+	return c.maxFeedLen
+}
+
+// AddPostID puts the post into the feed at its place by time, adding the same post again changes nothing.
+// Only maxFeedLen newest posts are kept.
+func (c *Cache) AddPostID(ctx context.Context, key string, value string, createdAt time.Time) error {
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, postFeedCachePrefix+key, redis.Z{
+			Score:  float64(createdAt.UnixMilli()),
+			Member: value,
+		})
+		pipe.ZRemRangeByRank(ctx, postFeedCachePrefix+key, 0, int64(-c.maxFeedLen-1))
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postfeedcache failed to add value for key %q: %w", key, err)
+	}
+
+	return nil
+}
+
+// AddPostsIDs puts the posts into the feed at once, createdAt maps posts ids to their creation time.
+func (c *Cache) AddPostsIDs(ctx context.Context, key string, createdAt map[string]time.Time) error {
+	if len(createdAt) == 0 {
+		return nil
+	}
+
+	members := make([]redis.Z, 0, len(createdAt))
+
+	for postID, postCreatedAt := range createdAt {
+		members = append(members, redis.Z{
+			Score:  float64(postCreatedAt.UnixMilli()),
+			Member: postID,
+		})
+	}
+
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, postFeedCachePrefix+key, members...)
+		pipe.ZRemRangeByRank(ctx, postFeedCachePrefix+key, 0, int64(-c.maxFeedLen-1))
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postfeedcache failed to add values for key %q: %w", key, err)
 	}
+
+	return nil
 }
 
-func (c *Cache) AddPostID(ctx context.Context, key string, value string) error {
-	_, err := c.redisDB.GetClient().LPush(ctx, postFeedCachePrefix+key, value).Result()
+// AddPostIDToFeeds puts the post into the feeds of the given keys in a single round trip. Expired feeds are skipped,
+// they are rebuilt on read. It returns the number of feeds the post was put into.
+func (c *Cache) AddPostIDToFeeds(ctx context.Context, keys []string, value string, createdAt time.Time) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	scriptKeys := make([]string, 0, len(keys)*3)
+	for _, key := range keys {
+		scriptKeys = append(scriptKeys, postFeedBuiltCachePrefix+key, postFeedBuildingCachePrefix+key, postFeedCachePrefix+key)
+	}
+
+	written, err := addToLiveFeedsScript.Run(ctx, c.redisDB.GetClient(), scriptKeys,
+		createdAt.UnixMilli(), value, c.maxFeedLen).Int()
 	if err != nil {
-		return fmt.Errorf("postfeedcache failed to push value for key %q: %w", key, err)
+		return 0, fmt.Errorf("postfeedcache failed to add value to %d feeds: %w", len(keys), err)
 	}
 
-	_, err = c.redisDB.GetClient().LTrim(ctx, key, 0, maxListLen-1).Result()
+	return written, nil
+}
+
+// RemovePostIDFromFeeds removes the post from the feeds of all the given keys in a single round trip.
+func (c *Cache) RemovePostIDFromFeeds(ctx context.Context, keys []string, value string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.ZRem(ctx, postFeedCachePrefix+key, value)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("postfeedcache failed to trim list size: %w", err)
+		return fmt.Errorf("postfeedcache failed to remove value from %d feeds: %w", len(keys), err)
 	}
 
 	return nil
 }
 
-func (c *Cache) RemovePostID(ctx context.Context, key string, value string) error {
-	_, err := c.redisDB.GetClient().LRem(ctx, postFeedCachePrefix+key, 0, value).Result()
+// RemovePostsIDs removes the posts from the feed at once.
+func (c *Cache) RemovePostsIDs(ctx context.Context, key string, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		members = append(members, value)
+	}
+
+	_, err := c.redisDB.GetClient().ZRem(ctx, postFeedCachePrefix+key, members...).Result()
 	if err != nil {
-		return fmt.Errorf("postfeedcache failed to remove value from the list for key %q: %w", key, err)
+		return fmt.Errorf("postfeedcache failed to remove values from the feed for key %q: %w", key, err)
 	}
 
 	return nil
 }
 
+// GetPostsIDs returns the whole feed, the newest posts first.
 func (c *Cache) GetPostsIDs(ctx context.Context, key string) ([]string, error) {
-	values, err := c.redisDB.GetClient().LRange(ctx, postFeedCachePrefix+key, 0, -1).Result()
+	values, err := c.redisDB.GetClient().ZRevRange(ctx, postFeedCachePrefix+key, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("postfeedcache failed to fetch elements for key %q: %w", key, err)
+	}
+
+	return values, nil
+}
+
+// GetPostsIDsRange returns count posts ids starting from offset, the newest first.
+func (c *Cache) GetPostsIDsRange(ctx context.Context, key string, offset, count int) ([]string, error) {
+	values, err := c.redisDB.GetClient().ZRevRange(ctx, postFeedCachePrefix+key, int64(offset), int64(offset+count-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("postfeedcache failed to fetch elements for key %q: %w", key, err)
+	}
+
+	return values, nil
+}
+
+// GetPostsIDsAfter returns up to count posts ids following the given post in the feed, the newest first.
+// When the post is no longer in the feed, the posts created not later than it are returned.
+func (c *Cache) GetPostsIDsAfter(ctx context.Context, key string, afterValue string, afterCreatedAt time.Time, count int) ([]string, error) {
+	var start int64
+
+	if afterValue != "" {
+		rank, err := c.redisDB.GetClient().ZRevRank(ctx, postFeedCachePrefix+key, afterValue).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return c.getPostsIDsByScore(ctx, key, afterCreatedAt, count)
+			}
+
+			return nil, fmt.Errorf("postfeedcache failed to find value in the feed for key %q: %w", key, err)
+		}
+
+		start = rank + 1
+	}
+
+	values, err := c.redisDB.GetClient().ZRevRange(ctx, postFeedCachePrefix+key, start, start+int64(count)-1).Result()
 	if err != nil {
 		return nil, fmt.Errorf("postfeedcache failed to fetch elements for key %q: %w", key, err)
 	}
@@ -57,8 +224,21 @@ func (c *Cache) GetPostsIDs(ctx context.Context, key string) ([]string, error) {
 	return values, nil
 }
 
+func (c *Cache) getPostsIDsByScore(ctx context.Context, key string, createdBefore time.Time, count int) ([]string, error) {
+	values, err := c.redisDB.GetClient().ZRevRangeByScore(ctx, postFeedCachePrefix+key, &redis.ZRangeBy{
+		Max:   strconv.FormatInt(createdBefore.UnixMilli(), 10),
+		Min:   "-inf",
+		Count: int64(count),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("postfeedcache failed to fetch elements by score for key %q: %w", key, err)
+	}
+
+	return values, nil
+}
+
 func (c *Cache) SetLastRetrievedAt(ctx context.Context, key string, lastRetrievedAtTimestamp int64) error {
-	_, err := c.redisDB.GetClient().Set(ctx, postFeedLastRetrievedAtCachePrefix+key, lastRetrievedAtTimestamp, 0).Result()
+	_, err := c.redisDB.GetClient().Set(ctx, postFeedLastRetrievedAtCachePrefix+key, lastRetrievedAtTimestamp, c.feedTTL).Result()
 	if err != nil {
 		return fmt.Errorf("postfeedcache failed to set last retrieved timestamp: %w", err)
 	}
@@ -78,3 +258,160 @@ func (c *Cache) GetLastRetrievedAt(ctx context.Context, key string) (int64, erro
 
 	return lastRetrievedAtTimestampMilli, nil
 }
+
+// IsBuilt tells whether the feed has been built since it was lost from the cache. An empty feed has no key,
+// so the mark tells a cold feed from an empty one.
+func (c *Cache) IsBuilt(ctx context.Context, key string) (bool, error) {
+	count, err := c.redisDB.GetClient().Exists(ctx, postFeedBuiltCachePrefix+key).Result()
+	if err != nil {
+		return false, fmt.Errorf("postfeedcache failed to check built mark for key %q: %w", key, err)
+	}
+
+	return count > 0, nil
+}
+
+// SetBuilt marks the feed built, the feed and the mark expire together.
+func (c *Cache) SetBuilt(ctx context.Context, key string) error {
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, postFeedBuiltCachePrefix+key, 1, c.feedTTL)
+
+		if c.feedTTL > 0 {
+			pipe.Expire(ctx, postFeedCachePrefix+key, c.feedTTL)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postfeedcache failed to set built mark for key %q: %w", key, err)
+	}
+
+	return nil
+}
+
+// SetBuilding marks the feed as being rebuilt for ttl. The fan-out writes to such a feed too,
+// so the posts published after the rebuild has read the db are not lost.
+func (c *Cache) SetBuilding(ctx context.Context, key string, ttl time.Duration) error {
+	_, err := c.redisDB.GetClient().Set(ctx, postFeedBuildingCachePrefix+key, 1, ttl).Result()
+	if err != nil {
+		return fmt.Errorf("postfeedcache failed to set building mark for key %q: %w", key, err)
+	}
+
+	return nil
+}
+
+func (c *Cache) DeleteBuilding(ctx context.Context, key string) error {
+	_, err := c.redisDB.GetClient().Del(ctx, postFeedBuildingCachePrefix+key).Result()
+	if err != nil {
+		return fmt.Errorf("postfeedcache failed to delete building mark for key %q: %w", key, err)
+	}
+
+	return nil
+}
+
+// Touch postpones the feed expiration, it is called when the user reads the feed.
+func (c *Cache) Touch(ctx context.Context, key string) error {
+	if c.feedTTL == 0 {
+		return nil
+	}
+
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Expire(ctx, postFeedCachePrefix+key, c.feedTTL)
+		pipe.Expire(ctx, postFeedBuiltCachePrefix+key, c.feedTTL)
+		pipe.Expire(ctx, postFeedLastRetrievedAtCachePrefix+key, c.feedTTL)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postfeedcache failed to touch feed for key %q: %w", key, err)
+	}
+
+	return nil
+}
+
+// AcquireRebuildLock takes the feed rebuild lock for the owner, false is returned when it is held by another one.
+func (c *Cache) AcquireRebuildLock(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error) {
+	acquired, err := c.redisDB.GetClient().SetNX(ctx, postFeedRebuildLockCachePrefix+key, owner, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("postfeedcache failed to acquire rebuild lock for key %q: %w", key, err)
+	}
+
+	return acquired, nil
+}
+
+func (c *Cache) ReleaseRebuildLock(ctx context.Context, key string, owner string) error {
+	err := releaseLockScript.Run(ctx, c.redisDB.GetClient(), []string{postFeedRebuildLockCachePrefix + key}, owner).Err()
+	if err != nil {
+		return fmt.Errorf("postfeedcache failed to release rebuild lock for key %q: %w", key, err)
+	}
+
+	return nil
+}
+
+// GetRebuildProgress returns the id of the last user whose feed was rebuilt by the bulk rebuild, empty when there is none.
+func (c *Cache) GetRebuildProgress(ctx context.Context) (string, error) {
+	lastUserID, err := c.redisDB.GetClient().Get(ctx, postFeedRebuildProgressCacheKey).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("postfeedcache failed to get rebuild progress: %w", err)
+	}
+
+	return lastUserID, nil
+}
+
+func (c *Cache) SetRebuildProgress(ctx context.Context, lastUserID string) error {
+	_, err := c.redisDB.GetClient().Set(ctx, postFeedRebuildProgressCacheKey, lastUserID, 0).Result()
+	if err != nil {
+		return fmt.Errorf("postfeedcache failed to set rebuild progress: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Cache) DeleteRebuildProgress(ctx context.Context) error {
+	_, err := c.redisDB.GetClient().Del(ctx, postFeedRebuildProgressCacheKey).Result()
+	if err != nil {
+		return fmt.Errorf("postfeedcache failed to delete rebuild progress: %w", err)
+	}
+
+	return nil
+}
+
+// AddRebuildFailed remembers the user whose feed the bulk rebuild failed to rebuild, so it is retried later.
+func (c *Cache) AddRebuildFailed(ctx context.Context, userID string) error {
+	_, err := c.redisDB.GetClient().SAdd(ctx, postFeedRebuildFailedCacheKey, userID).Result()
+	if err != nil {
+		return fmt.Errorf("postfeedcache failed to add rebuild failed user %q: %w", userID, err)
+	}
+
+	return nil
+}
+
+func (c *Cache) GetRebuildFailed(ctx context.Context) ([]string, error) {
+	userIDs, err := c.redisDB.GetClient().SMembers(ctx, postFeedRebuildFailedCacheKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("postfeedcache failed to get rebuild failed users: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+func (c *Cache) RemoveRebuildFailed(ctx context.Context, userID string) error {
+	_, err := c.redisDB.GetClient().SRem(ctx, postFeedRebuildFailedCacheKey, userID).Result()
+	if err != nil {
+		return fmt.Errorf("postfeedcache failed to remove rebuild failed user %q: %w", userID, err)
+	}
+
+	return nil
+}
+
+func (c *Cache) DeleteRebuildFailed(ctx context.Context) error {
+	_, err := c.redisDB.GetClient().Del(ctx, postFeedRebuildFailedCacheKey).Result()
+	if err != nil {
+		return fmt.Errorf("postfeedcache failed to delete rebuild failed users: %w", err)
+	}
+
+	return nil
+}
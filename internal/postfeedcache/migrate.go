@@ -0,0 +1,173 @@
+package postfeedcache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const migrateScanCount = 100
+
+// MigrateLists converts feeds left as lists by the previous versions into sorted sets.
+// createdAtByIDs gives the creation time of the posts, the posts it does not know are dropped.
+// Feeds already converted are skipped, so it is safe to run again. It returns the number of converted feeds.
+func (c *Cache) MigrateLists(ctx context.Context, createdAtByIDs func(ctx context.Context, postIDs []string) (map[string]time.Time, error)) (int, error) {
+	client := c.redisDB.GetClient()
+
+	var (
+		cursor   uint64
+		migrated int
+	)
+
+	for {
+		keys, nextCursor, err := client.ScanType(ctx, cursor, postFeedCachePrefix+"*", migrateScanCount, "list").Result()
+		if err != nil {
+			return migrated, fmt.Errorf("postfeedcache failed to scan feed keys: %w", err)
+		}
+
+		for _, key := range keys {
+			err := c.migrateList(ctx, key, createdAtByIDs)
+			if err != nil {
+				return migrated, err
+			}
+
+			migrated++
+		}
+
+		cursor = nextCursor
+		if cursor == 0 {
+			return migrated, nil
+		}
+	}
+}
+
+func (c *Cache) migrateList(ctx context.Context, key string, createdAtByIDs func(ctx context.Context, postIDs []string) (map[string]time.Time, error)) error {
+	client := c.redisDB.GetClient()
+
+	postIDs, err := client.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("postfeedcache failed to fetch list %q: %w", key, err)
+	}
+
+	var createdAt map[string]time.Time
+
+	if len(postIDs) > 0 {
+		createdAt, err = createdAtByIDs(ctx, postIDs)
+		if err != nil {
+			return fmt.Errorf("postfeedcache failed to get posts creation time: %w", err)
+		}
+	}
+
+	members := make([]redis.Z, 0, len(createdAt))
+
+	for postID, postCreatedAt := range createdAt {
+		members = append(members, redis.Z{
+			Score:  float64(postCreatedAt.UnixMilli()),
+			Member: postID,
+		})
+	}
+
+	tmpKey := key + ":migrating"
+
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, tmpKey)
+
+		if len(members) == 0 {
+			pipe.Del(ctx, key)
+
+			return nil
+		}
+
+		pipe.ZAdd(ctx, tmpKey, members...)
+		pipe.ZRemRangeByRank(ctx, tmpKey, 0, int64(-c.maxFeedLen-1))
+		pipe.Rename(ctx, tmpKey, key)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postfeedcache failed to convert list %q: %w", key, err)
+	}
+
+	return nil
+}
+
+// ExpireFeeds sets the feed expiration to the feeds and their marks left without one, e.g. by the previous versions.
+// It does nothing when the feeds are kept forever. It returns the number of keys the expiration was set to.
+func (c *Cache) ExpireFeeds(ctx context.Context) (int, error) {
+	if c.feedTTL == 0 {
+		return 0, nil
+	}
+
+	var expired int
+
+	for _, prefix := range []string{postFeedCachePrefix, postFeedBuiltCachePrefix, postFeedLastRetrievedAtCachePrefix} {
+		prefixExpired, err := c.expireKeys(ctx, prefix)
+		expired += prefixExpired
+
+		if err != nil {
+			return expired, err
+		}
+	}
+
+	return expired, nil
+}
+
+func (c *Cache) expireKeys(ctx context.Context, prefix string) (int, error) {
+	client := c.redisDB.GetClient()
+
+	var (
+		cursor  uint64
+		expired int
+	)
+
+	for {
+		keys, nextCursor, err := client.Scan(ctx, cursor, prefix+"*", migrateScanCount).Result()
+		if err != nil {
+			return expired, fmt.Errorf("postfeedcache failed to scan keys %q: %w", prefix, err)
+		}
+
+		ttlCmds := make([]*redis.DurationCmd, 0, len(keys))
+
+		_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				ttlCmds = append(ttlCmds, pipe.TTL(ctx, key))
+			}
+
+			return nil
+		})
+		if err != nil {
+			return expired, fmt.Errorf("postfeedcache failed to get keys %q expiration: %w", prefix, err)
+		}
+
+		// only the keys without an expiration are changed, so the feeds being read keep theirs
+		var persistentKeys []string
+
+		for i, cmd := range ttlCmds {
+			if cmd.Val() == -1 {
+				persistentKeys = append(persistentKeys, keys[i])
+			}
+		}
+
+		if len(persistentKeys) > 0 {
+			_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for _, key := range persistentKeys {
+					pipe.Expire(ctx, key, c.feedTTL)
+				}
+
+				return nil
+			})
+			if err != nil {
+				return expired, fmt.Errorf("postfeedcache failed to expire keys %q: %w", prefix, err)
+			}
+
+			expired += len(persistentKeys)
+		}
+
+		cursor = nextCursor
+		if cursor == 0 {
+			return expired, nil
+		}
+	}
+}
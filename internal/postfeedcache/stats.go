@@ -0,0 +1,120 @@
+package postfeedcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	statsScanCount = 100
+	// statsMaxScannedKeys bounds the keys a stats call walks, the stats of a bigger keyspace are estimated.
+	statsMaxScannedKeys = 10000
+)
+
+type Stats struct {
+	FeedsCount  int64
+	PostsCount  int64
+	MemoryBytes int64
+	// Estimated tells that only a part of the keyspace was walked and the counts are extrapolated to the whole of it.
+	Estimated bool
+}
+
+// Stats sums the lengths of the feeds and the memory they take. The feeds marks are not counted.
+// It walks at most statsMaxScannedKeys keys, when there are more the counts are extrapolated by the keyspace size.
+func (c *Cache) Stats(ctx context.Context) (*Stats, error) {
+	client := c.redisDB.GetClient()
+
+	var (
+		stats   Stats
+		cursor  uint64
+		scanned int64
+	)
+
+	for {
+		// the keys are matched here rather than by SCAN, so the walked keys are counted for the extrapolation
+		keys, nextCursor, err := client.Scan(ctx, cursor, "", statsScanCount).Result()
+		if err != nil {
+			return nil, fmt.Errorf("postfeedcache failed to scan keys: %w", err)
+		}
+
+		scanned += int64(len(keys))
+
+		var feedKeys []string
+
+		for _, key := range keys {
+			if strings.HasPrefix(key, postFeedCachePrefix) {
+				feedKeys = append(feedKeys, key)
+			}
+		}
+
+		err = c.addFeedsStats(ctx, &stats, feedKeys)
+		if err != nil {
+			return nil, err
+		}
+
+		cursor = nextCursor
+		if cursor == 0 {
+			return &stats, nil
+		}
+
+		if scanned >= statsMaxScannedKeys {
+			break
+		}
+	}
+
+	keysCount, err := client.DBSize(ctx).Result()
+	if err != nil {
+		return nil, fmt.Errorf("postfeedcache failed to get keys count: %w", err)
+	}
+
+	if keysCount > scanned {
+		stats.FeedsCount = stats.FeedsCount * keysCount / scanned
+		stats.PostsCount = stats.PostsCount * keysCount / scanned
+		stats.MemoryBytes = stats.MemoryBytes * keysCount / scanned
+		stats.Estimated = true
+	}
+
+	return &stats, nil
+}
+
+// addFeedsStats adds the lengths and the memory of the feeds to the stats. A feed Redis failed to measure,
+// e.g. expired in between, is skipped.
+func (c *Cache) addFeedsStats(ctx context.Context, stats *Stats, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	cardCmds := make([]*redis.IntCmd, 0, len(keys))
+	memoryCmds := make([]*redis.IntCmd, 0, len(keys))
+
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			cardCmds = append(cardCmds, pipe.ZCard(ctx, key))
+			memoryCmds = append(memoryCmds, pipe.MemoryUsage(ctx, key))
+		}
+
+		return nil
+	})
+
+	// the replies of Redis are checked per command below, only a failed round trip fails the stats
+	var redisErr redis.Error
+	if err != nil && !errors.As(err, &redisErr) {
+		return fmt.Errorf("postfeedcache failed to get feeds sizes: %w", err)
+	}
+
+	for i := range keys {
+		if cardCmds[i].Err() != nil || memoryCmds[i].Err() != nil || cardCmds[i].Val() == 0 {
+			continue
+		}
+
+		stats.FeedsCount++
+		stats.PostsCount += cardCmds[i].Val()
+		stats.MemoryBytes += memoryCmds[i].Val()
+	}
+
+	return nil
+}
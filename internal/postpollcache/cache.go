@@ -0,0 +1,299 @@
+package postpollcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"myfacebook/internal/rdb"
+	"myfacebook/internal/repository"
+)
+
+const (
+	postPollCachePrefix  = "postpolls:post_"
+	pollCachePrefix      = "postpolls:poll:post_"
+	userVotesCachePrefix = "postpolls:votes:user_"
+	votersField          = "voters"
+	cacheTTL             = 24 * time.Hour
+)
+
+// voteScript counts a vote only when the counts have already been loaded,
+// otherwise a partially filled hash would be served as complete.
+var voteScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("HINCRBY", KEYS[1], "voters", 1)
+for _, optionID in ipairs(ARGV) do
+	redis.call("HINCRBY", KEYS[1], optionID, 1)
+end
+return 1
+`)
+
+type Cache struct {
+	redisDB *rdb.RedisDB
+}
+
+func New(redisDB *rdb.RedisDB) *Cache {
+	return &Cache{
+		redisDB: redisDB,
+	}
+}
+
+func (c *Cache) Vote(ctx context.Context, postID string, optionIDs []int) error {
+	args := make([]interface{}, 0, len(optionIDs))
+	for _, optionID := range optionIDs {
+		args = append(args, optionID)
+	}
+
+	err := voteScript.Run(ctx, c.redisDB.GetClient(), []string{postPollCachePrefix + postID}, args...).Err()
+	if err != nil {
+		return fmt.Errorf("postpollcache failed to count vote for post %q: %w", postID, err)
+	}
+
+	return nil
+}
+
+func (c *Cache) SetCounts(ctx context.Context, postID string, counts repository.PollCounts) error {
+	values := make([]interface{}, 0, len(counts.Options)*2+2)
+	values = append(values, votersField, counts.Voters)
+
+	for optionID, count := range counts.Options {
+		values = append(values, strconv.Itoa(optionID), count)
+	}
+
+	key := postPollCachePrefix + postID
+
+	_, err := c.redisDB.GetClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key, values...)
+		pipe.Expire(ctx, key, cacheTTL)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postpollcache failed to set counts for post %q: %w", postID, err)
+	}
+
+	return nil
+}
+
+// GetCounts returns cached poll counts and the ids of posts missing in the cache.
+func (c *Cache) GetCounts(ctx context.Context, postIDs []string) (map[string]repository.PollCounts, []string, error) {
+	cmds := make([]*redis.MapStringStringCmd, 0, len(postIDs))
+
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, postID := range postIDs {
+			cmds = append(cmds, pipe.HGetAll(ctx, postPollCachePrefix+postID))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("postpollcache failed to get counts: %w", err)
+	}
+
+	counts := make(map[string]repository.PollCounts, len(postIDs))
+
+	var missedPostsIDs []string
+
+	for i, cmd := range cmds {
+		values := cmd.Val()
+		if len(values) == 0 {
+			missedPostsIDs = append(missedPostsIDs, postIDs[i])
+
+			continue
+		}
+
+		postCounts := repository.PollCounts{Options: make(map[int]int, len(values))}
+
+		for field, value := range values {
+			count, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("postpollcache failed to parse count %q: %w", value, err)
+			}
+
+			if field == votersField {
+				postCounts.Voters = count
+
+				continue
+			}
+
+			optionID, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, nil, fmt.Errorf("postpollcache failed to parse option id %q: %w", field, err)
+			}
+
+			postCounts.Options[optionID] = count
+		}
+
+		counts[postIDs[i]] = postCounts
+	}
+
+	return counts, missedPostsIDs, nil
+}
+
+// SetPolls caches the polls of the posts, the posts missing in polls are cached as having no poll.
+func (c *Cache) SetPolls(ctx context.Context, postIDs []string, polls map[string]repository.Poll) error {
+	values := make(map[string]string, len(postIDs))
+
+	for _, postID := range postIDs {
+		poll, ok := polls[postID]
+		if !ok {
+			values[postID] = ""
+
+			continue
+		}
+
+		pollJSON, err := json.Marshal(poll)
+		if err != nil {
+			return fmt.Errorf("postpollcache failed to marshal poll of post %q: %w", postID, err)
+		}
+
+		values[postID] = string(pollJSON)
+	}
+
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for postID, value := range values {
+			pipe.Set(ctx, pollCachePrefix+postID, value, cacheTTL)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postpollcache failed to set polls: %w", err)
+	}
+
+	return nil
+}
+
+// GetPolls returns cached polls and the ids of posts missing in the cache, the posts cached without a poll are not returned.
+func (c *Cache) GetPolls(ctx context.Context, postIDs []string) (map[string]repository.Poll, []string, error) {
+	keys := make([]string, 0, len(postIDs))
+	for _, postID := range postIDs {
+		keys = append(keys, pollCachePrefix+postID)
+	}
+
+	values, err := c.redisDB.GetClient().MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("postpollcache failed to get polls: %w", err)
+	}
+
+	polls := make(map[string]repository.Poll, len(postIDs))
+
+	var missedPostsIDs []string
+
+	for i, value := range values {
+		if value == nil {
+			missedPostsIDs = append(missedPostsIDs, postIDs[i])
+
+			continue
+		}
+
+		pollJSON := value.(string)
+		if pollJSON == "" {
+			continue
+		}
+
+		var poll repository.Poll
+
+		if err := json.Unmarshal([]byte(pollJSON), &poll); err != nil {
+			return nil, nil, fmt.Errorf("postpollcache failed to unmarshal poll of post %q: %w", postIDs[i], err)
+		}
+
+		polls[postIDs[i]] = poll
+	}
+
+	return polls, missedPostsIDs, nil
+}
+
+// SetUserVotes writes the vote of the user in the poll of the post through to the cache.
+func (c *Cache) SetUserVotes(ctx context.Context, userID, postID string, optionIDs []int) error {
+	key := userVotesCachePrefix + userID
+
+	_, err := c.redisDB.GetClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, postID, joinOptionIDs(optionIDs))
+		pipe.Expire(ctx, key, cacheTTL)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postpollcache failed to set votes of user %q for post %q: %w", userID, postID, err)
+	}
+
+	return nil
+}
+
+// FillUserVotes fills the cache with the votes of the user loaded from the db, the posts missing in votes
+// are cached as not voted. A vote written through meanwhile is not overwritten.
+func (c *Cache) FillUserVotes(ctx context.Context, userID string, postIDs []string, votes map[string][]int) error {
+	key := userVotesCachePrefix + userID
+
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, postID := range postIDs {
+			pipe.HSetNX(ctx, key, postID, joinOptionIDs(votes[postID]))
+		}
+
+		pipe.Expire(ctx, key, cacheTTL)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postpollcache failed to set votes of user %q: %w", userID, err)
+	}
+
+	return nil
+}
+
+// GetUserVotes returns cached votes of the user and the ids of posts missing in the cache.
+func (c *Cache) GetUserVotes(ctx context.Context, userID string, postIDs []string) (map[string][]int, []string, error) {
+	values, err := c.redisDB.GetClient().HMGet(ctx, userVotesCachePrefix+userID, postIDs...).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("postpollcache failed to get votes of user %q: %w", userID, err)
+	}
+
+	votes := make(map[string][]int, len(postIDs))
+
+	var missedPostsIDs []string
+
+	for i, value := range values {
+		if value == nil {
+			missedPostsIDs = append(missedPostsIDs, postIDs[i])
+
+			continue
+		}
+
+		joinedOptionIDs := value.(string)
+		if joinedOptionIDs == "" {
+			continue
+		}
+
+		optionIDs := make([]int, 0, strings.Count(joinedOptionIDs, ",")+1)
+
+		for _, field := range strings.Split(joinedOptionIDs, ",") {
+			optionID, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, nil, fmt.Errorf("postpollcache failed to parse option id %q: %w", field, err)
+			}
+
+			optionIDs = append(optionIDs, optionID)
+		}
+
+		votes[postIDs[i]] = optionIDs
+	}
+
+	return votes, missedPostsIDs, nil
+}
+
+func joinOptionIDs(optionIDs []int) string {
+	fields := make([]string, 0, len(optionIDs))
+	for _, optionID := range optionIDs {
+		fields = append(fields, strconv.Itoa(optionID))
+	}
+
+	return strings.Join(fields, ",")
+}
@@ -0,0 +1,112 @@
+package postpurger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"myfacebook/internal/postviewcache"
+	"myfacebook/internal/repository"
+)
+
+const batchSize = 100
+
+type Purger struct {
+	postRepository      repository.PostRepository
+	postDraftRepository repository.PostDraftRepository
+	postViewCache       *postviewcache.Cache
+	retention           time.Duration
+	interval            time.Duration
+
+	done chan struct{}
+	wg   *sync.WaitGroup
+}
+
+func New(postRepository repository.PostRepository, postDraftRepository repository.PostDraftRepository, postViewCache *postviewcache.Cache,
+	retention, interval time.Duration,
+) *Purger {
+	return &Purger{
+		postRepository:      postRepository,
+		postDraftRepository: postDraftRepository,
+		postViewCache:       postViewCache,
+		retention:           retention,
+		interval:            interval,
+		done:                make(chan struct{}),
+		wg:                  &sync.WaitGroup{},
+	}
+}
+
+func (p *Purger) Start(ctx context.Context) {
+	p.wg.Add(1)
+
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := p.purge(ctx); err != nil {
+					slog.Error(fmt.Sprintf("Error on purging deleted posts: %s", err))
+				}
+			case <-p.done:
+				return
+			}
+		}
+	}()
+
+	slog.Info("Successfully started post purger")
+}
+
+func (p *Purger) purge(ctx context.Context) error {
+	expiredDrafts, err := p.postDraftRepository.DeleteExpired(ctx)
+	if err != nil {
+		return fmt.Errorf("postpurger failed to delete expired post drafts: %w", err)
+	}
+
+	if expiredDrafts > 0 {
+		slog.Info(fmt.Sprintf("Deleted %d expired post drafts", expiredDrafts))
+	}
+
+	deletedBefore := time.Now().Add(-p.retention)
+
+	for {
+		purgedPostsIDs, err := p.postRepository.PurgeDeleted(ctx, deletedBefore, batchSize)
+		if err != nil {
+			return fmt.Errorf("postpurger failed to purge deleted posts: %w", err)
+		}
+
+		if len(purgedPostsIDs) > 0 {
+			slog.Info(fmt.Sprintf("Purged %d deleted posts", len(purgedPostsIDs)))
+		}
+
+		// the viewers left would expire anyway, they are dropped not to hold the memory till then
+		err = p.postViewCache.DeleteViews(ctx, purgedPostsIDs)
+		if err != nil {
+			return fmt.Errorf("postpurger failed to delete views of purged posts: %w", err)
+		}
+
+		if len(purgedPostsIDs) < batchSize {
+			return nil
+		}
+
+		select {
+		case <-p.done:
+			return nil
+		default:
+		}
+	}
+}
+
+func (p *Purger) Stop() {
+	slog.Info("Stopping post purger...")
+
+	close(p.done)
+	p.wg.Wait()
+
+	slog.Info("Post purger stopped")
+}
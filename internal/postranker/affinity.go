@@ -0,0 +1,75 @@
+package postranker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"myfacebook/internal/repository"
+)
+
+const (
+	// the number of mutual friends and dialog messages at which the affinity part gets half of its score
+	mutualFriendsHalfScore  = 10
+	dialogMessagesHalfScore = 20
+)
+
+// AffinitySignal scores the posts by how close the user is to the post author: the number of their mutual friends
+// and of the messages in their dialog.
+type AffinitySignal struct {
+	userRepository   repository.UserRepository
+	dialogRepository repository.DialogRepository
+}
+
+func NewAffinitySignal(userRepository repository.UserRepository, dialogRepository repository.DialogRepository) *AffinitySignal {
+	return &AffinitySignal{
+		userRepository:   userRepository,
+		dialogRepository: dialogRepository,
+	}
+}
+
+func (s *AffinitySignal) Scores(ctx context.Context, userID string, posts []repository.Post) (map[string]float64, error) {
+	var authorsIDs []string
+
+	seenAuthorsIDs := make(map[string]struct{}, len(posts))
+
+	for _, post := range posts {
+		if _, ok := seenAuthorsIDs[post.AuthorID]; ok {
+			continue
+		}
+
+		seenAuthorsIDs[post.AuthorID] = struct{}{}
+		authorsIDs = append(authorsIDs, post.AuthorID)
+	}
+
+	mutualFriendsCounts, err := s.userRepository.GetMutualFriendsCounts(ctx, userID, authorsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("affinity signal failed to get mutual friends counts: %w", err)
+	}
+
+	authorsScores := make(map[string]float64, len(authorsIDs))
+
+	for _, authorID := range authorsIDs {
+		// the dialogs live in the dialog service, the feed is ranked without them when it is not available
+		dialogMessages, err := s.dialogRepository.GetDialogMessagesBySenderIDAndReceiverID(ctx, userID, authorID)
+		if err != nil {
+			slog.Error(fmt.Sprintf("Affinity signal failed to get dialog messages of users %s and %s: %s", userID, authorID, err))
+		}
+
+		authorsScores[authorID] = (saturate(mutualFriendsCounts[authorID], mutualFriendsHalfScore) +
+			saturate(len(dialogMessages), dialogMessagesHalfScore)) / 2
+	}
+
+	scores := make(map[string]float64, len(posts))
+
+	for _, post := range posts {
+		scores[post.ID] = authorsScores[post.AuthorID]
+	}
+
+	return scores, nil
+}
+
+// saturate maps a count to [0, 1), the half count gives 0.5.
+func saturate(count, halfCount int) float64 {
+	return float64(count) / float64(count+halfCount)
+}
@@ -0,0 +1,98 @@
+package postranker
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"myfacebook/internal/postreactioncache"
+	"myfacebook/internal/repository"
+)
+
+const (
+	reactionWeight = 1
+	commentWeight  = 2
+	shareWeight    = 3
+)
+
+// EngagementSignal scores the posts by their reactions, comments and shares relative to the most engaging candidate,
+// on the log scale so a single viral post does not flatten the others.
+type EngagementSignal struct {
+	postRepository         repository.PostRepository
+	postReactionRepository repository.PostReactionRepository
+	commentRepository      repository.CommentRepository
+	postReactionCache      *postreactioncache.Cache
+}
+
+func NewEngagementSignal(postRepository repository.PostRepository, postReactionRepository repository.PostReactionRepository,
+	commentRepository repository.CommentRepository, postReactionCache *postreactioncache.Cache,
+) *EngagementSignal {
+	return &EngagementSignal{
+		postRepository:         postRepository,
+		postReactionRepository: postReactionRepository,
+		commentRepository:      commentRepository,
+		postReactionCache:      postReactionCache,
+	}
+}
+
+func (s *EngagementSignal) Scores(ctx context.Context, _ string, posts []repository.Post) (map[string]float64, error) {
+	postsIDs := make([]string, 0, len(posts))
+	for _, post := range posts {
+		postsIDs = append(postsIDs, post.ID)
+	}
+
+	reactionsCounts, missedPostsIDs, err := s.postReactionCache.GetCounts(ctx, postsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("engagement signal failed to get reactions counts from cache: %w", err)
+	}
+
+	if len(missedPostsIDs) > 0 {
+		missedReactionsCounts, err := s.postReactionRepository.GetCountsByPostIDs(ctx, missedPostsIDs)
+		if err != nil {
+			return nil, fmt.Errorf("engagement signal failed to get reactions counts from repo: %w", err)
+		}
+
+		for postID, postCounts := range missedReactionsCounts {
+			reactionsCounts[postID] = postCounts
+		}
+	}
+
+	commentsCounts, err := s.commentRepository.GetCountsByPostIDs(ctx, postsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("engagement signal failed to get comments counts: %w", err)
+	}
+
+	sharesCounts, err := s.postRepository.GetSharesCountsByPostIDs(ctx, postsIDs)
+	if err != nil {
+		return nil, fmt.Errorf("engagement signal failed to get shares counts: %w", err)
+	}
+
+	engagements := make(map[string]float64, len(posts))
+
+	var maxEngagement float64
+
+	for _, post := range posts {
+		var reactionsCount int
+		for _, count := range reactionsCounts[post.ID] {
+			reactionsCount += count
+		}
+
+		engagement := math.Log1p(float64(reactionWeight*reactionsCount +
+			commentWeight*commentsCounts[post.ID] + shareWeight*sharesCounts[post.ID]))
+
+		engagements[post.ID] = engagement
+		maxEngagement = max(maxEngagement, engagement)
+	}
+
+	scores := make(map[string]float64, len(posts))
+
+	if maxEngagement == 0 {
+		return scores, nil
+	}
+
+	for postID, engagement := range engagements {
+		scores[postID] = engagement / maxEngagement
+	}
+
+	return scores, nil
+}
@@ -0,0 +1,85 @@
+package postranker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"myfacebook/internal/repository"
+)
+
+// Signal scores the candidate posts of the user feed by posts ids. Scores are expected to be in [0, 1],
+// posts missing from the result score 0.
+type Signal interface {
+	Scores(ctx context.Context, userID string, posts []repository.Post) (map[string]float64, error)
+}
+
+type Ranker interface {
+	Name() string
+	Rank(ctx context.Context, userID string, posts []repository.Post) ([]repository.Post, error)
+}
+
+type WeightedSignal struct {
+	Signal Signal
+	Weight float64
+}
+
+// WeightedRanker orders the posts by the weighted sum of the signals scores, the newest first on a tie.
+// Signals of zero weight are not computed at all.
+type WeightedRanker struct {
+	name    string
+	signals []WeightedSignal
+}
+
+func NewWeightedRanker(name string, signals ...WeightedSignal) *WeightedRanker {
+	return &WeightedRanker{
+		name:    name,
+		signals: signals,
+	}
+}
+
+func (r *WeightedRanker) Name() string {
+	return r.name
+}
+
+func (r *WeightedRanker) Rank(ctx context.Context, userID string, posts []repository.Post) ([]repository.Post, error) {
+	if len(posts) == 0 {
+		return posts, nil
+	}
+
+	totalScores := make(map[string]float64, len(posts))
+
+	for _, signal := range r.signals {
+		if signal.Weight == 0 {
+			continue
+		}
+
+		scores, err := signal.Signal.Scores(ctx, userID, posts)
+		if err != nil {
+			return nil, fmt.Errorf("postranker %s failed to score posts: %w", r.name, err)
+		}
+
+		for postID, score := range scores {
+			totalScores[postID] += signal.Weight * score
+		}
+	}
+
+	rankedPosts := make([]repository.Post, len(posts))
+	copy(rankedPosts, posts)
+
+	sort.SliceStable(rankedPosts, func(i, j int) bool {
+		left, right := rankedPosts[i], rankedPosts[j]
+
+		if totalScores[left.ID] != totalScores[right.ID] {
+			return totalScores[left.ID] > totalScores[right.ID]
+		}
+
+		if !left.CreatedAt.Equal(right.CreatedAt) {
+			return left.CreatedAt.After(right.CreatedAt)
+		}
+
+		return left.ID > right.ID
+	})
+
+	return rankedPosts, nil
+}
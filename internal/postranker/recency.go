@@ -0,0 +1,34 @@
+package postranker
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"myfacebook/internal/repository"
+)
+
+// RecencySignal halves the post score each half life passed since the post was created.
+type RecencySignal struct {
+	halfLife time.Duration
+}
+
+func NewRecencySignal(halfLife time.Duration) *RecencySignal {
+	return &RecencySignal{
+		halfLife: halfLife,
+	}
+}
+
+func (s *RecencySignal) Scores(_ context.Context, _ string, posts []repository.Post) (map[string]float64, error) {
+	scores := make(map[string]float64, len(posts))
+
+	now := time.Now()
+
+	for _, post := range posts {
+		age := max(now.Sub(post.CreatedAt), 0)
+
+		scores[post.ID] = math.Pow(0.5, float64(age)/float64(s.halfLife))
+	}
+
+	return scores, nil
+}
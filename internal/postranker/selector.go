@@ -0,0 +1,28 @@
+package postranker
+
+import (
+	"hash/fnv"
+)
+
+// Selector splits the users between the rankers for A/B tests. A user always gets the same ranker
+// as long as the rankers list stays the same.
+type Selector struct {
+	rankers []Ranker
+}
+
+func NewSelector(rankers ...Ranker) *Selector {
+	return &Selector{
+		rankers: rankers,
+	}
+}
+
+func (s *Selector) Select(userID string) Ranker {
+	if len(s.rankers) == 1 {
+		return s.rankers[0]
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(userID))
+
+	return s.rankers[hash.Sum32()%uint32(len(s.rankers))]
+}
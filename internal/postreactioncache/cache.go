@@ -0,0 +1,221 @@
+package postreactioncache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"myfacebook/internal/rdb"
+)
+
+const (
+	postReactionsCachePrefix = "postreactions:post_"
+	userReactionsCachePrefix = "postreactions:user_"
+	dirtyPostsIDsCacheKey    = "postreactions:dirty"
+	// placeholderField marks the hash as loaded, so a post without reactions is not a cache miss.
+	placeholderField = "_"
+	cacheTTL         = 24 * time.Hour
+)
+
+// incrScript increments a reaction counter only when the counts have already been loaded,
+// otherwise a partially filled hash would be served as complete.
+var incrScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("HINCRBY", KEYS[1], ARGV[1], ARGV[2])
+end
+return 0
+`)
+
+type Cache struct {
+	redisDB *rdb.RedisDB
+}
+
+func New(redisDB *rdb.RedisDB) *Cache {
+	return &Cache{
+		redisDB: redisDB,
+	}
+}
+
+func (c *Cache) Incr(ctx context.Context, postID, reactionType string, delta int64) error {
+	client := c.redisDB.GetClient()
+
+	err := incrScript.Run(ctx, client, []string{postReactionsCachePrefix + postID}, reactionType, delta).Err()
+	if err != nil {
+		return fmt.Errorf("postreactioncache failed to increment reaction %q for post %q: %w", reactionType, postID, err)
+	}
+
+	_, err = client.SAdd(ctx, dirtyPostsIDsCacheKey, postID).Result()
+	if err != nil {
+		return fmt.Errorf("postreactioncache failed to mark post %q as dirty: %w", postID, err)
+	}
+
+	return nil
+}
+
+func (c *Cache) SetCounts(ctx context.Context, postID string, counts map[string]int) error {
+	values := make([]interface{}, 0, len(counts)*2+2)
+	values = append(values, placeholderField, 0)
+
+	for reactionType, count := range counts {
+		values = append(values, reactionType, count)
+	}
+
+	key := postReactionsCachePrefix + postID
+
+	_, err := c.redisDB.GetClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key, values...)
+		pipe.Expire(ctx, key, cacheTTL)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postreactioncache failed to set counts for post %q: %w", postID, err)
+	}
+
+	return nil
+}
+
+// GetCounts returns cached reaction counts and the ids of posts missing in the cache.
+func (c *Cache) GetCounts(ctx context.Context, postIDs []string) (map[string]map[string]int, []string, error) {
+	cmds := make([]*redis.MapStringStringCmd, 0, len(postIDs))
+
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, postID := range postIDs {
+			cmds = append(cmds, pipe.HGetAll(ctx, postReactionsCachePrefix+postID))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("postreactioncache failed to get counts: %w", err)
+	}
+
+	counts := make(map[string]map[string]int, len(postIDs))
+
+	var missedPostsIDs []string
+
+	for i, cmd := range cmds {
+		values := cmd.Val()
+		if len(values) == 0 {
+			missedPostsIDs = append(missedPostsIDs, postIDs[i])
+
+			continue
+		}
+
+		postCounts := make(map[string]int, len(values))
+
+		for reactionType, value := range values {
+			if reactionType == placeholderField {
+				continue
+			}
+
+			count, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("postreactioncache failed to parse count %q: %w", value, err)
+			}
+
+			if count > 0 {
+				postCounts[reactionType] = count
+			}
+		}
+
+		counts[postIDs[i]] = postCounts
+	}
+
+	return counts, missedPostsIDs, nil
+}
+
+func (c *Cache) PopDirtyPostsIDs(ctx context.Context, count int64) ([]string, error) {
+	postsIDs, err := c.redisDB.GetClient().SPopN(ctx, dirtyPostsIDsCacheKey, count).Result()
+	if err != nil {
+		return nil, fmt.Errorf("postreactioncache failed to pop dirty posts ids: %w", err)
+	}
+
+	return postsIDs, nil
+}
+
+// MarkDirtyPostsIDs puts the posts back to the dirty set, e.g. when reconciling the popped ones failed.
+func (c *Cache) MarkDirtyPostsIDs(ctx context.Context, postsIDs []string) error {
+	if len(postsIDs) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, 0, len(postsIDs))
+	for _, postID := range postsIDs {
+		members = append(members, postID)
+	}
+
+	_, err := c.redisDB.GetClient().SAdd(ctx, dirtyPostsIDsCacheKey, members...).Result()
+	if err != nil {
+		return fmt.Errorf("postreactioncache failed to mark posts as dirty: %w", err)
+	}
+
+	return nil
+}
+
+// SetUserReaction writes the reaction of the user to the post through to the cache, empty reactionType means no reaction.
+func (c *Cache) SetUserReaction(ctx context.Context, userID, postID, reactionType string) error {
+	key := userReactionsCachePrefix + userID
+
+	_, err := c.redisDB.GetClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, postID, reactionType)
+		pipe.Expire(ctx, key, cacheTTL)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postreactioncache failed to set reaction of user %q for post %q: %w", userID, postID, err)
+	}
+
+	return nil
+}
+
+// FillUserReactions fills the cache with the reactions of the user loaded from the db, the posts missing in reactions
+// are cached as having no reaction. A reaction written through meanwhile is not overwritten.
+func (c *Cache) FillUserReactions(ctx context.Context, userID string, postIDs []string, reactions map[string]string) error {
+	key := userReactionsCachePrefix + userID
+
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, postID := range postIDs {
+			pipe.HSetNX(ctx, key, postID, reactions[postID])
+		}
+
+		pipe.Expire(ctx, key, cacheTTL)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postreactioncache failed to set reactions of user %q: %w", userID, err)
+	}
+
+	return nil
+}
+
+// GetUserReactions returns cached reactions of the user and the ids of posts missing in the cache.
+func (c *Cache) GetUserReactions(ctx context.Context, userID string, postIDs []string) (map[string]string, []string, error) {
+	values, err := c.redisDB.GetClient().HMGet(ctx, userReactionsCachePrefix+userID, postIDs...).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("postreactioncache failed to get reactions of user %q: %w", userID, err)
+	}
+
+	reactions := make(map[string]string, len(postIDs))
+
+	var missedPostsIDs []string
+
+	for i, value := range values {
+		if value == nil {
+			missedPostsIDs = append(missedPostsIDs, postIDs[i])
+
+			continue
+		}
+
+		if reactionType := value.(string); reactionType != "" {
+			reactions[postIDs[i]] = reactionType
+		}
+	}
+
+	return reactions, missedPostsIDs, nil
+}
@@ -0,0 +1,119 @@
+package postreactionreconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"myfacebook/internal/postreactioncache"
+	"myfacebook/internal/repository"
+)
+
+const batchSize = 100
+
+type Reconciler struct {
+	postReactionRepository repository.PostReactionRepository
+	postReactionCache      *postreactioncache.Cache
+	interval               time.Duration
+
+	done chan struct{}
+	wg   *sync.WaitGroup
+}
+
+func New(postReactionRepository repository.PostReactionRepository, postReactionCache *postreactioncache.Cache, interval time.Duration) *Reconciler {
+	return &Reconciler{
+		postReactionRepository: postReactionRepository,
+		postReactionCache:      postReactionCache,
+		interval:               interval,
+		done:                   make(chan struct{}),
+		wg:                     &sync.WaitGroup{},
+	}
+}
+
+func (r *Reconciler) Start(ctx context.Context) {
+	r.wg.Add(1)
+
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.reconcile(ctx); err != nil {
+					slog.Error(fmt.Sprintf("Error on reconciling post reactions: %s", err))
+				}
+			case <-r.done:
+				return
+			}
+		}
+	}()
+
+	slog.Info("Successfully started post reaction reconciler")
+}
+
+func (r *Reconciler) reconcile(ctx context.Context) error {
+	for {
+		postsIDs, err := r.postReactionCache.PopDirtyPostsIDs(ctx, batchSize)
+		if err != nil {
+			return fmt.Errorf("postreactionreconciler failed to pop dirty posts ids: %w", err)
+		}
+
+		if len(postsIDs) == 0 {
+			return nil
+		}
+
+		err = r.reconcileBatch(ctx, postsIDs)
+		if err != nil {
+			// the popped posts are left dirty, so the next run reconciles them
+			if markErr := r.postReactionCache.MarkDirtyPostsIDs(ctx, postsIDs); markErr != nil {
+				return errors.Join(err, fmt.Errorf("postreactionreconciler failed to put back dirty posts ids: %w", markErr))
+			}
+
+			return err
+		}
+
+		select {
+		case <-r.done:
+			return nil
+		default:
+		}
+	}
+}
+
+// reconcileBatch overwrites the cached counts of the posts with the ones from the repo. A post without reactions
+// gets empty counts, so counters left by removed reactions do not outlive the reconciling.
+func (r *Reconciler) reconcileBatch(ctx context.Context, postsIDs []string) error {
+	counts, err := r.postReactionRepository.GetCountsByPostIDs(ctx, postsIDs)
+	if err != nil {
+		return fmt.Errorf("postreactionreconciler failed to get reactions counts from repo: %w", err)
+	}
+
+	for _, postID := range postsIDs {
+		postCounts, ok := counts[postID]
+		if !ok {
+			postCounts = map[string]int{}
+		}
+
+		err := r.postReactionCache.SetCounts(ctx, postID, postCounts)
+		if err != nil {
+			return fmt.Errorf("postreactionreconciler failed to set reactions counts to cache: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Reconciler) Stop() {
+	slog.Info("Stopping post reaction reconciler...")
+
+	close(r.done)
+	r.wg.Wait()
+
+	slog.Info("Post reaction reconciler stopped")
+}
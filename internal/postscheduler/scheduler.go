@@ -0,0 +1,161 @@
+package postscheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"myfacebook/internal/repository"
+	"myfacebook/internal/rmq"
+)
+
+const batchSize = 100
+
+type postFeedRMQMessage struct {
+	Operation      string    `json:"operation"`
+	PostID         string    `json:"post_id"`
+	PostText       string    `json:"post_text,omitempty"`
+	AuthorID       string    `json:"author_id"`
+	OriginalPostID string    `json:"original_post_id,omitempty"`
+	Visibility     string    `json:"visibility,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type Scheduler struct {
+	postRepository repository.PostRepository
+	rmq            *rmq.RMQ
+	interval       time.Duration
+
+	done chan struct{}
+	wg   *sync.WaitGroup
+}
+
+func New(postRepository repository.PostRepository, rmq *rmq.RMQ, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		postRepository: postRepository,
+		rmq:            rmq,
+		interval:       interval,
+		done:           make(chan struct{}),
+		wg:             &sync.WaitGroup{},
+	}
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.publishDuePosts(ctx); err != nil {
+					slog.Error(fmt.Sprintf("Error on publishing scheduled posts: %s", err))
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}()
+
+	slog.Info("Successfully started post scheduler")
+}
+
+// publishDuePosts publishes the due posts and then fans out the published ones. A post stays marked to be fanned out
+// until its message is sent, so a post published before a failure or a crash is fanned out on the next tick.
+func (s *Scheduler) publishDuePosts(ctx context.Context) error {
+	for {
+		publishedCount, err := s.postRepository.PublishDuePosts(ctx, batchSize)
+		if err != nil {
+			return fmt.Errorf("postscheduler failed to publish due posts: %w", err)
+		}
+
+		if publishedCount < batchSize {
+			break
+		}
+
+		if s.stopping() {
+			return nil
+		}
+	}
+
+	for {
+		posts, err := s.postRepository.GetFanoutPendingPosts(ctx, batchSize)
+		if err != nil {
+			return fmt.Errorf("postscheduler failed to get fan-out pending posts: %w", err)
+		}
+
+		fannedOutPostIDs := make([]string, 0, len(posts))
+
+		var publishErr error
+
+		for _, post := range posts {
+			publishErr = s.publishPostFeedRMQMessage(ctx, post)
+			if publishErr != nil {
+				break
+			}
+
+			fannedOutPostIDs = append(fannedOutPostIDs, post.ID)
+		}
+
+		// a post whose message went out but is not marked is fanned out again, adding a post to a feed twice changes nothing
+		err = s.postRepository.MarkFannedOut(ctx, fannedOutPostIDs)
+		if err != nil {
+			return fmt.Errorf("postscheduler failed to mark posts fanned out: %w", err)
+		}
+
+		if publishErr != nil {
+			return publishErr
+		}
+
+		if len(posts) < batchSize || s.stopping() {
+			return nil
+		}
+	}
+}
+
+func (s *Scheduler) stopping() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Scheduler) publishPostFeedRMQMessage(ctx context.Context, post repository.Post) error {
+	postFeedRMQMsg, err := json.Marshal(postFeedRMQMessage{
+		Operation:      "add",
+		PostID:         post.ID,
+		PostText:       post.Text,
+		AuthorID:       post.AuthorID,
+		OriginalPostID: post.OriginalPostID,
+		Visibility:     post.Visibility,
+		CreatedAt:      post.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("postscheduler failed to make rmq message: %w", err)
+	}
+
+	err = s.rmq.Publish(ctx, "", "/post/feed", postFeedRMQMsg)
+	if err != nil {
+		return fmt.Errorf("postscheduler failed to publish rmq message: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Scheduler) Stop() {
+	slog.Info("Stopping post scheduler...")
+
+	close(s.done)
+	s.wg.Wait()
+
+	slog.Info("Post scheduler stopped")
+}
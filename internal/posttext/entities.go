@@ -0,0 +1,104 @@
+package posttext
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	EntityTypeHashtag = "hashtag"
+	EntityTypeMention = "mention"
+)
+
+// surrogateSelf is the first rune encoded with a surrogate pair in UTF-16.
+const surrogateSelf = 0x10000
+
+var entityRegexp = regexp.MustCompile(
+	`(?:^|[^\p{L}\p{N}_])(?:#([\p{L}\p{N}_]+)|@([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}))`)
+
+// Entity is a hashtag or a mention found in a post text.
+// Offset and Length are measured in UTF-16 code units, so clients can linkify the text as is.
+type Entity struct {
+	Type   string `json:"type"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	Value  string `json:"value"`
+}
+
+func ParseEntities(text string) []Entity {
+	matches := entityRegexp.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	entities := make([]Entity, 0, len(matches))
+
+	for _, match := range matches {
+		entity := Entity{Type: EntityTypeHashtag}
+
+		valueStart, valueEnd := match[2], match[3]
+		if valueStart < 0 {
+			entity.Type = EntityTypeMention
+			valueStart, valueEnd = match[4], match[5]
+		}
+
+		// include the leading # or @
+		entityStart := valueStart - 1
+
+		entity.Offset = utf16Len(text[:entityStart])
+		entity.Length = utf16Len(text[entityStart:valueEnd])
+		entity.Value = strings.ToLower(text[valueStart:valueEnd])
+
+		entities = append(entities, entity)
+	}
+
+	return entities
+}
+
+// Hashtags returns unique hashtags of the entities.
+func Hashtags(entities []Entity) []string {
+	return uniqueValues(entities, EntityTypeHashtag)
+}
+
+// Mentions returns unique ids of the mentioned users.
+func Mentions(entities []Entity) []string {
+	return uniqueValues(entities, EntityTypeMention)
+}
+
+func uniqueValues(entities []Entity, entityType string) []string {
+	seen := make(map[string]struct{}, len(entities))
+
+	var values []string
+
+	for _, entity := range entities {
+		if entity.Type != entityType {
+			continue
+		}
+
+		if _, ok := seen[entity.Value]; ok {
+			continue
+		}
+
+		seen[entity.Value] = struct{}{}
+
+		values = append(values, entity.Value)
+	}
+
+	return values
+}
+
+func utf16Len(s string) int {
+	length := 0
+
+	for _, r := range s {
+		if r >= surrogateSelf {
+			length += 2
+
+			continue
+		}
+
+		length++
+	}
+
+	return length
+}
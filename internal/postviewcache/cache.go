@@ -0,0 +1,102 @@
+package postviewcache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"myfacebook/internal/rdb"
+)
+
+const (
+	postViewsCachePrefix  = "post:views:"
+	dirtyPostsIDsCacheKey = "postviews:dirty"
+	// viewsTTL lets the viewers of posts not viewed for a while go, the counts flushed to post_stats never decrease,
+	// so a post viewed again after that keeps its count.
+	viewsTTL = 30 * 24 * time.Hour
+)
+
+// Cache counts unique viewers of posts with HyperLogLog, a key takes at most 12KB whatever the number of viewers.
+type Cache struct {
+	redisDB *rdb.RedisDB
+}
+
+func New(redisDB *rdb.RedisDB) *Cache {
+	return &Cache{
+		redisDB: redisDB,
+	}
+}
+
+func (c *Cache) AddViews(ctx context.Context, viewerID string, postsIDs []string) error {
+	members := make([]interface{}, 0, len(postsIDs))
+
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, postID := range postsIDs {
+			pipe.PFAdd(ctx, postViewsCachePrefix+postID, viewerID)
+			pipe.Expire(ctx, postViewsCachePrefix+postID, viewsTTL)
+
+			members = append(members, postID)
+		}
+
+		pipe.SAdd(ctx, dirtyPostsIDsCacheKey, members...)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("postviewcache failed to add views of viewer %q: %w", viewerID, err)
+	}
+
+	return nil
+}
+
+func (c *Cache) GetUniqueViewers(ctx context.Context, postsIDs []string) (map[string]int64, error) {
+	cmds := make([]*redis.IntCmd, 0, len(postsIDs))
+
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, postID := range postsIDs {
+			cmds = append(cmds, pipe.PFCount(ctx, postViewsCachePrefix+postID))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("postviewcache failed to count unique viewers: %w", err)
+	}
+
+	uniqueViewers := make(map[string]int64, len(postsIDs))
+
+	for i, cmd := range cmds {
+		uniqueViewers[postsIDs[i]] = cmd.Val()
+	}
+
+	return uniqueViewers, nil
+}
+
+// DeleteViews drops the viewers of the posts, e.g. of the purged ones.
+func (c *Cache) DeleteViews(ctx context.Context, postsIDs []string) error {
+	if len(postsIDs) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(postsIDs))
+	for _, postID := range postsIDs {
+		keys = append(keys, postViewsCachePrefix+postID)
+	}
+
+	_, err := c.redisDB.GetClient().Del(ctx, keys...).Result()
+	if err != nil {
+		return fmt.Errorf("postviewcache failed to delete views: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Cache) PopDirtyPostsIDs(ctx context.Context, count int64) ([]string, error) {
+	postsIDs, err := c.redisDB.GetClient().SPopN(ctx, dirtyPostsIDsCacheKey, count).Result()
+	if err != nil {
+		return nil, fmt.Errorf("postviewcache failed to pop dirty posts ids: %w", err)
+	}
+
+	return postsIDs, nil
+}
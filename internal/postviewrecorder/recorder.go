@@ -0,0 +1,144 @@
+package postviewrecorder
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"myfacebook/internal/postviewcache"
+	"myfacebook/internal/repository"
+)
+
+const (
+	batchSize = 100
+	queueSize = 10000
+)
+
+type view struct {
+	viewerID string
+	postsIDs []string
+}
+
+// Recorder records post views in the background, so reads do not wait for redis,
+// and periodically flushes unique viewers counts to the db.
+type Recorder struct {
+	postViewCache       *postviewcache.Cache
+	postStatsRepository repository.PostStatsRepository
+	flushInterval       time.Duration
+
+	views chan view
+	done  chan struct{}
+	wg    *sync.WaitGroup
+}
+
+func New(postViewCache *postviewcache.Cache, postStatsRepository repository.PostStatsRepository, flushInterval time.Duration) *Recorder {
+	return &Recorder{
+		postViewCache:       postViewCache,
+		postStatsRepository: postStatsRepository,
+		flushInterval:       flushInterval,
+		views:               make(chan view, queueSize),
+		done:                make(chan struct{}),
+		wg:                  &sync.WaitGroup{},
+	}
+}
+
+func (r *Recorder) Start(ctx context.Context) {
+	r.wg.Add(2)
+
+	go func() {
+		defer r.wg.Done()
+
+		for {
+			select {
+			case v := <-r.views:
+				if err := r.postViewCache.AddViews(ctx, v.viewerID, v.postsIDs); err != nil {
+					slog.Error(fmt.Sprintf("Error on recording post views: %s", err))
+				}
+			case <-r.done:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.flushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.flush(ctx); err != nil {
+					slog.Error(fmt.Sprintf("Error on flushing post views: %s", err))
+				}
+			case <-r.done:
+				return
+			}
+		}
+	}()
+
+	slog.Info("Successfully started post view recorder")
+}
+
+// Record queues views of the posts by the viewer, own posts are not counted.
+// It never blocks, views are dropped when the queue is full.
+func (r *Recorder) Record(viewerID string, posts []repository.Post) {
+	postsIDs := make([]string, 0, len(posts))
+
+	for _, post := range posts {
+		if post.AuthorID != viewerID {
+			postsIDs = append(postsIDs, post.ID)
+		}
+	}
+
+	if len(postsIDs) == 0 {
+		return
+	}
+
+	select {
+	case r.views <- view{viewerID: viewerID, postsIDs: postsIDs}:
+	default:
+		slog.Warn("Post views queue is full, views are dropped")
+	}
+}
+
+func (r *Recorder) flush(ctx context.Context) error {
+	for {
+		postsIDs, err := r.postViewCache.PopDirtyPostsIDs(ctx, batchSize)
+		if err != nil {
+			return fmt.Errorf("postviewrecorder failed to pop dirty posts ids: %w", err)
+		}
+
+		if len(postsIDs) == 0 {
+			return nil
+		}
+
+		uniqueViewers, err := r.postViewCache.GetUniqueViewers(ctx, postsIDs)
+		if err != nil {
+			return fmt.Errorf("postviewrecorder failed to get unique viewers from cache: %w", err)
+		}
+
+		err = r.postStatsRepository.SetUniqueViewers(ctx, uniqueViewers)
+		if err != nil {
+			return fmt.Errorf("postviewrecorder failed to set unique viewers to repo: %w", err)
+		}
+
+		select {
+		case <-r.done:
+			return nil
+		default:
+		}
+	}
+}
+
+func (r *Recorder) Stop() {
+	slog.Info("Stopping post view recorder...")
+
+	close(r.done)
+	r.wg.Wait()
+
+	slog.Info("Post view recorder stopped")
+}
@@ -0,0 +1,189 @@
+package postvisibility
+
+import (
+	"context"
+	"fmt"
+
+	"myfacebook/internal/repository"
+)
+
+type Checker struct {
+	postRepository repository.PostRepository
+	userRepository repository.UserRepository
+}
+
+func New(postRepository repository.PostRepository, userRepository repository.UserRepository) *Checker {
+	return &Checker{
+		postRepository: postRepository,
+		userRepository: userRepository,
+	}
+}
+
+func IsValid(visibility string) bool {
+	switch visibility {
+	case repository.PostVisibilityPublic, repository.PostVisibilityFriends,
+		repository.PostVisibilityPrivate, repository.PostVisibilityList:
+		return true
+	}
+
+	return false
+}
+
+func (c *Checker) CanView(ctx context.Context, viewerID string, post repository.Post) (bool, error) {
+	if post.AuthorID == viewerID {
+		return true, nil
+	}
+
+	if !isPublished(post) {
+		return false, nil
+	}
+
+	switch post.Visibility {
+	case repository.PostVisibilityPublic, "":
+		return true, nil
+	case repository.PostVisibilityFriends:
+		isFriend, err := c.userRepository.HasFriend(ctx, post.AuthorID, viewerID)
+		if err != nil {
+			return false, fmt.Errorf("postvisibility failed to check author friend: %w", err)
+		}
+
+		return isFriend, nil
+	case repository.PostVisibilityList:
+		postsIDs, err := c.postRepository.GetAudiencePostsIDs(ctx, viewerID, []string{post.ID})
+		if err != nil {
+			return false, fmt.Errorf("postvisibility failed to get audience posts ids: %w", err)
+		}
+
+		return len(postsIDs) > 0, nil
+	}
+
+	return false, nil
+}
+
+// FilterVisible returns the posts the viewer may see, keeping their order.
+func (c *Checker) FilterVisible(ctx context.Context, viewerID string, posts []repository.Post) ([]repository.Post, error) {
+	var hasFriendsPosts bool
+
+	var listPostsIDs []string
+
+	for _, post := range posts {
+		if post.AuthorID == viewerID {
+			continue
+		}
+
+		switch post.Visibility {
+		case repository.PostVisibilityFriends:
+			hasFriendsPosts = true
+		case repository.PostVisibilityList:
+			listPostsIDs = append(listPostsIDs, post.ID)
+		}
+	}
+
+	// authors that have the viewer as a friend
+	friendAuthorsIDs := make(map[string]struct{})
+
+	if hasFriendsPosts {
+		usersIDs, err := c.userRepository.GetUsersIDsByFriendID(ctx, viewerID)
+		if err != nil {
+			return nil, fmt.Errorf("postvisibility failed to get users ids by friend id: %w", err)
+		}
+
+		for _, userID := range usersIDs {
+			friendAuthorsIDs[userID] = struct{}{}
+		}
+	}
+
+	audiencePostsIDs := make(map[string]struct{})
+
+	if len(listPostsIDs) > 0 {
+		postsIDs, err := c.postRepository.GetAudiencePostsIDs(ctx, viewerID, listPostsIDs)
+		if err != nil {
+			return nil, fmt.Errorf("postvisibility failed to get audience posts ids: %w", err)
+		}
+
+		for _, postID := range postsIDs {
+			audiencePostsIDs[postID] = struct{}{}
+		}
+	}
+
+	visiblePosts := make([]repository.Post, 0, len(posts))
+
+	for _, post := range posts {
+		if post.AuthorID != viewerID {
+			if !isPublished(post) {
+				continue
+			}
+
+			switch post.Visibility {
+			case repository.PostVisibilityPublic, "":
+			case repository.PostVisibilityFriends:
+				if _, ok := friendAuthorsIDs[post.AuthorID]; !ok {
+					continue
+				}
+			case repository.PostVisibilityList:
+				if _, ok := audiencePostsIDs[post.ID]; !ok {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+
+		visiblePosts = append(visiblePosts, post)
+	}
+
+	return visiblePosts, nil
+}
+
+// FilterAudience returns the users, out of the given ones, that may see the post, keeping their order.
+// Only the given users are looked up, so a large audience is checked chunk by chunk.
+func (c *Checker) FilterAudience(ctx context.Context, post repository.Post, usersIDs []string) ([]string, error) {
+	var allowedUsersIDs []string
+
+	switch post.Visibility {
+	case repository.PostVisibilityPublic, "":
+		return usersIDs, nil
+	case repository.PostVisibilityFriends:
+		friendsIDs, err := c.userRepository.GetFriendsIDsAmong(ctx, post.AuthorID, usersIDs)
+		if err != nil {
+			return nil, fmt.Errorf("postvisibility failed to get author friends ids: %w", err)
+		}
+
+		allowedUsersIDs = friendsIDs
+	case repository.PostVisibilityList:
+		audienceIDs, err := c.postRepository.GetAudienceAmong(ctx, post.ID, usersIDs)
+		if err != nil {
+			return nil, fmt.Errorf("postvisibility failed to get post audience: %w", err)
+		}
+
+		allowedUsersIDs = audienceIDs
+	default:
+		return nil, nil
+	}
+
+	allowed := toSet(allowedUsersIDs)
+
+	var filteredUsersIDs []string
+
+	for _, userID := range usersIDs {
+		if _, ok := allowed[userID]; ok {
+			filteredUsersIDs = append(filteredUsersIDs, userID)
+		}
+	}
+
+	return filteredUsersIDs, nil
+}
+
+func isPublished(post repository.Post) bool {
+	return post.Status == repository.PostStatusPublished || post.Status == ""
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+
+	return set
+}
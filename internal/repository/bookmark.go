@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type Bookmark struct {
+	UserID    string    `db:"user_id"`
+	PostID    string    `db:"post_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type BookmarkRepository interface {
+	Add(ctx context.Context, userID, postID string) error
+	Delete(ctx context.Context, userID, postID string) error
+	GetBookmarksByUserID(ctx context.Context, userID string, before *Cursor, limit int) ([]Bookmark, error)
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type Comment struct {
+	ID        string    `db:"id"`
+	PostID    string    `db:"post_id"`
+	ParentID  string    `db:"parent_id"`
+	AuthorID  string    `db:"author_id"`
+	Text      string    `db:"text"`
+	CreatedAt time.Time `db:"created_at"`
+	Deleted   bool      `db:"deleted"`
+	// Depth is the number of replies between the comment and the one its thread is read from.
+	Depth int `db:"depth"`
+	// HasHiddenReplies tells that the comment is at the depth a thread is cut at and has replies of its own.
+	HasHiddenReplies bool `db:"has_hidden_replies"`
+}
+
+type CommentRepository interface {
+	Add(ctx context.Context, comment Comment) error
+	GetCommentByID(ctx context.Context, commentID string) (*Comment, error)
+	Update(ctx context.Context, comment Comment) error
+	Delete(ctx context.Context, commentID string) error
+	Hide(ctx context.Context, commentID string) error
+	Unhide(ctx context.Context, commentID string) error
+	GetThreadsByPostID(ctx context.Context, postID string, after *Cursor, limit, maxDepth, maxReplies int) ([]Comment, error)
+	GetRepliesByCommentID(ctx context.Context, postID, commentID string, after *Cursor, limit, maxDepth int) ([]Comment, error)
+	GetCountsByPostIDs(ctx context.Context, postIDs []string) (map[string]int, error)
+}
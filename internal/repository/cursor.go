@@ -0,0 +1,8 @@
+package repository
+
+import "time"
+
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type PollOption struct {
+	ID   int    `db:"option_id"`
+	Text string `db:"text"`
+}
+
+type Poll struct {
+	PostID         string     `db:"post_id"`
+	MultipleChoice bool       `db:"multiple_choice"`
+	ClosesAt       *time.Time `db:"closes_at"`
+	Options        []PollOption
+}
+
+func (p Poll) IsClosed() bool {
+	return p.ClosesAt != nil && !p.ClosesAt.After(time.Now())
+}
+
+type PollCounts struct {
+	Voters  int
+	Options map[int]int
+}
+
+type PollRepository interface {
+	Add(ctx context.Context, poll Poll) error
+	GetPollsByPostIDs(ctx context.Context, postIDs []string) (map[string]Poll, error)
+	Vote(ctx context.Context, postID, userID string, optionIDs []int) error
+	GetCountsByPostIDs(ctx context.Context, postIDs []string) (map[string]PollCounts, error)
+	GetUserVotesByPostIDs(ctx context.Context, userID string, postIDs []string) (map[string][]int, error)
+}
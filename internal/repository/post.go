@@ -2,19 +2,77 @@ package repository
 
 import (
 	"context"
+	"time"
+)
+
+const (
+	PostVisibilityPublic  = "public"
+	PostVisibilityFriends = "friends"
+	PostVisibilityPrivate = "private"
+	PostVisibilityList    = "list"
+)
+
+const (
+	PostStatusPublished = "published"
+	PostStatusScheduled = "scheduled"
+	PostStatusHeld      = "held"
+	PostStatusRejected  = "rejected"
+)
+
+// The reasons a post is held for.
+const (
+	PostHeldReasonModeration = "moderation"
+	PostHeldReasonReports    = "reports"
 )
 
 type Post struct {
-	ID       string `db:"id"`
-	Text     string `db:"text"`
-	AuthorID string `db:"author_id"`
+	ID             string     `db:"id"`
+	Text           string     `db:"text"`
+	AuthorID       string     `db:"author_id"`
+	OriginalPostID string     `db:"original_post_id"`
+	Visibility     string     `db:"visibility"`
+	Status         string     `db:"status"`
+	HeldReason     string     `db:"held_reason"`
+	PublishAt      *time.Time `db:"publish_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+}
+
+type PostSearchResult struct {
+	Post
+	Rank    float64 `db:"rank"`
+	Snippet string  `db:"snippet"`
 }
 
 type PostRepository interface {
 	Add(ctx context.Context, post Post) error
-	Delete(ctx context.Context, postID, authorID string) error
+	Delete(ctx context.Context, postID, authorID string) (*Post, error)
+	RemoveByModeration(ctx context.Context, postID string) (*Post, error)
+	Restore(ctx context.Context, postID, authorID string) (*Post, error)
+	GetDeletedPostsByAuthorID(ctx context.Context, authorID string, before *Cursor, limit int) ([]Post, error)
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) ([]string, error)
 	GetPostByID(ctx context.Context, postID string) (*Post, error)
 	GetPostsByIDs(ctx context.Context, postIDs []string, offset, limit int) ([]Post, error)
+	GetFriendsPosts(ctx context.Context, userID string, since time.Time, limit int) ([]Post, error)
 	GetLastPostsIDsByAuthorIDs(ctx context.Context, authorIDs []string, createdAfterTimestamp int64, limit int) ([]string, error)
 	Update(ctx context.Context, post Post) error
+	GetSharesCountsByPostIDs(ctx context.Context, postIDs []string) (map[string]int, error)
+	SetTags(ctx context.Context, postID string, tags []string) error
+	SetMentions(ctx context.Context, postID string, userIDs []string) ([]string, error)
+	GetPostsByTag(ctx context.Context, tag string, before *Cursor, limit int) ([]Post, error)
+	GetPostsByAuthorID(ctx context.Context, authorID string, before *Cursor, limit int) ([]Post, error)
+	SearchPosts(ctx context.Context, viewerID, query string, offset, limit int) ([]PostSearchResult, error)
+	SetAudience(ctx context.Context, postID string, userIDs []string) error
+	GetAudienceAmong(ctx context.Context, postID string, userIDs []string) ([]string, error)
+	GetAudiencePostsIDs(ctx context.Context, userID string, postIDs []string) ([]string, error)
+	GetScheduledPostsByAuthorID(ctx context.Context, authorID string) ([]Post, error)
+	Reschedule(ctx context.Context, postID, authorID string, publishAt time.Time) error
+	CancelScheduled(ctx context.Context, postID, authorID string) error
+	PublishDuePosts(ctx context.Context, limit int) (int, error)
+	GetFanoutPendingPosts(ctx context.Context, limit int) ([]Post, error)
+	MarkFannedOut(ctx context.Context, postIDs []string) error
+	GetHeldPosts(ctx context.Context, offset, limit int) ([]Post, error)
+	ApproveHeld(ctx context.Context, postID string) (*Post, error)
+	ReleaseReportHold(ctx context.Context, postID string) (*Post, error)
+	RejectHeld(ctx context.Context, postID string) error
+	Hold(ctx context.Context, postID string) (*Post, error)
 }
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type PostDraft struct {
+	ID         string
+	AuthorID   string
+	Text       string
+	Visibility string
+	Audience   []string
+	UpdatedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+type PostDraftRepository interface {
+	Add(ctx context.Context, draft PostDraft, ttl time.Duration) error
+	Update(ctx context.Context, draft PostDraft, ttl time.Duration) error
+	GetDraftsByAuthorID(ctx context.Context, authorID string) ([]PostDraft, error)
+	Claim(ctx context.Context, draftID, authorID string) (*PostDraft, error)
+	DeleteExpired(ctx context.Context) (int, error)
+}
@@ -0,0 +1,16 @@
+package repository
+
+import "context"
+
+type PostReaction struct {
+	PostID string `db:"post_id"`
+	UserID string `db:"user_id"`
+	Type   string `db:"type"`
+}
+
+type PostReactionRepository interface {
+	Set(ctx context.Context, reaction PostReaction) (string, error)
+	Delete(ctx context.Context, postID, userID string) (string, error)
+	GetCountsByPostIDs(ctx context.Context, postIDs []string) (map[string]map[string]int, error)
+	GetUserReactionsByPostIDs(ctx context.Context, userID string, postIDs []string) (map[string]string, error)
+}
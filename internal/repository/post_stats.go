@@ -0,0 +1,13 @@
+package repository
+
+import "context"
+
+type PostStats struct {
+	PostID        string `db:"post_id"`
+	UniqueViewers int64  `db:"unique_viewers"`
+}
+
+type PostStatsRepository interface {
+	SetUniqueViewers(ctx context.Context, uniqueViewers map[string]int64) error
+	GetPostStatsByPostID(ctx context.Context, postID string) (*PostStats, error)
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	ReportTargetPost    = "post"
+	ReportTargetComment = "comment"
+	ReportTargetUser    = "user"
+	ReportTargetMessage = "message"
+
+	ReportStatusOpen     = "open"
+	ReportStatusClaimed  = "claimed"
+	ReportStatusResolved = "resolved"
+
+	ReportActionDismiss       = "dismiss"
+	ReportActionDeleteContent = "delete_content"
+	ReportActionBanUser       = "ban_user"
+)
+
+type Report struct {
+	ID             string     `db:"id"`
+	TargetType     string     `db:"target_type"`
+	TargetID       string     `db:"target_id"`
+	TargetAuthorID string     `db:"target_author_id"`
+	ReporterID     string     `db:"reporter_id"`
+	Reason         string     `db:"reason"`
+	Comment        string     `db:"comment"`
+	Status         string     `db:"status"`
+	AssigneeID     string     `db:"assignee_id"`
+	Action         string     `db:"action"`
+	CreatedAt      time.Time  `db:"created_at"`
+	ResolvedAt     *time.Time `db:"resolved_at"`
+}
+
+type ReportRepository interface {
+	Add(ctx context.Context, report Report) error
+	GetReportByID(ctx context.Context, reportID string) (*Report, error)
+	GetReportsByStatus(ctx context.Context, status string, offset, limit int) ([]Report, error)
+	GetPendingCountByTarget(ctx context.Context, targetType, targetID string) (int, error)
+	Claim(ctx context.Context, reportID, assigneeID string) error
+	ResolveByTarget(ctx context.Context, targetType, targetID, assigneeID, action string) error
+}
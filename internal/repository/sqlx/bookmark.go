@@ -0,0 +1,80 @@
+package sqlx
+
+import (
+	"context"
+	"fmt"
+
+	"myfacebook/internal/db"
+	"myfacebook/internal/repository"
+)
+
+type BookmarkRepository struct {
+	writeDB *db.DB
+	readDB  *db.DB
+}
+
+func NewBookmarkRepository(writeDB, readDB *db.DB) *BookmarkRepository {
+	return &BookmarkRepository{
+		writeDB: writeDB,
+		readDB:  readDB,
+	}
+}
+
+func (r *BookmarkRepository) Add(ctx context.Context, userID, postID string) error {
+	dbConn := r.writeDB.GetConnection()
+
+	_, err := dbConn.ExecContext(ctx, `INSERT INTO bookmarks (user_id, post_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, userID, postID)
+	if err != nil {
+		return fmt.Errorf("failed to add bookmark to db: %w", err)
+	}
+
+	return nil
+}
+
+func (r *BookmarkRepository) Delete(ctx context.Context, userID, postID string) error {
+	dbConn := r.writeDB.GetConnection()
+
+	res, err := dbConn.ExecContext(ctx, `DELETE FROM bookmarks WHERE user_id=$1 AND post_id=$2`, userID, postID)
+	if err != nil {
+		return fmt.Errorf("failed to delete bookmark from db: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetBookmarksByUserID returns a page of the user bookmarks, the most recently saved first.
+func (r *BookmarkRepository) GetBookmarksByUserID(ctx context.Context, userID string, before *repository.Cursor, limit int) ([]repository.Bookmark, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var bookmarks []repository.Bookmark
+
+	args := []interface{}{userID}
+
+	sqlQuery := `SELECT user_id, post_id, created_at FROM bookmarks WHERE user_id = $1`
+
+	if before != nil {
+		sqlQuery += ` AND (created_at, post_id) < ($2, $3)`
+
+		args = append(args, before.CreatedAt, before.ID)
+	}
+
+	sqlQuery += fmt.Sprintf(` ORDER BY created_at DESC, post_id DESC LIMIT $%d`, len(args)+1)
+
+	args = append(args, limit)
+
+	err := dbConn.SelectContext(ctx, &bookmarks, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmarks by user id: %w", err)
+	}
+
+	return bookmarks, nil
+}
@@ -0,0 +1,233 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"myfacebook/internal/db"
+	"myfacebook/internal/repository"
+)
+
+const commentColumns = `id, post_id, COALESCE(parent_id::text, '') AS parent_id, author_id, text, created_at, (deleted_at IS NOT NULL OR hidden_at IS NOT NULL) AS deleted`
+
+type CommentRepository struct {
+	writeDB *db.DB
+	readDB  *db.DB
+}
+
+func NewCommentRepository(writeDB, readDB *db.DB) *CommentRepository {
+	return &CommentRepository{
+		writeDB: writeDB,
+		readDB:  readDB,
+	}
+}
+
+func (r *CommentRepository) Add(ctx context.Context, comment repository.Comment) error {
+	dbConn := r.writeDB.GetConnection()
+
+	sqlQuery := `INSERT INTO comments (id, post_id, parent_id, author_id, text)
+				VALUES (:id, :post_id, NULLIF(:parent_id, '')::uuid, :author_id, :text)`
+
+	_, err := dbConn.NamedExecContext(ctx, sqlQuery, comment)
+	if err != nil {
+		return fmt.Errorf("failed to add comment to db: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepository) GetCommentByID(ctx context.Context, commentID string) (*repository.Comment, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var comment repository.Comment
+
+	sqlQuery := `SELECT ` + commentColumns + ` FROM comments WHERE id = $1 AND deleted_at IS NULL`
+
+	err := dbConn.GetContext(ctx, &comment, sqlQuery, commentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get comment by id: %w", err)
+	}
+
+	return &comment, nil
+}
+
+func (r *CommentRepository) Update(ctx context.Context, comment repository.Comment) error {
+	dbConn := r.writeDB.GetConnection()
+
+	sqlQuery := `UPDATE comments SET text=$3, updated_at=CURRENT_TIMESTAMP WHERE id=$1 AND author_id=$2 AND deleted_at IS NULL`
+
+	res, err := dbConn.ExecContext(ctx, sqlQuery, comment.ID, comment.AuthorID, comment.Text)
+	if err != nil {
+		return fmt.Errorf("failed to update comment in db: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *CommentRepository) Delete(ctx context.Context, commentID string) error {
+	dbConn := r.writeDB.GetConnection()
+
+	res, err := dbConn.ExecContext(ctx, `UPDATE comments SET deleted_at=CURRENT_TIMESTAMP WHERE id=$1 AND deleted_at IS NULL`, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment from db: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// Hide shows the comment as deleted until its reports are reviewed.
+func (r *CommentRepository) Hide(ctx context.Context, commentID string) error {
+	dbConn := r.writeDB.GetConnection()
+
+	_, err := dbConn.ExecContext(ctx, `UPDATE comments SET hidden_at=CURRENT_TIMESTAMP WHERE id=$1 AND hidden_at IS NULL`, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to hide comment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepository) Unhide(ctx context.Context, commentID string) error {
+	dbConn := r.writeDB.GetConnection()
+
+	_, err := dbConn.ExecContext(ctx, `UPDATE comments SET hidden_at=NULL WHERE id=$1`, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to unhide comment: %w", err)
+	}
+
+	return nil
+}
+
+// GetThreadsByPostID returns a page of root comments of the post together with their replies down to maxDepth.
+// A thread gets at most maxReplies + 1 replies, the earliest ones, so the caller can tell there are more of them.
+// The replies are in the order they were written, so a reply always comes after the one it replies to.
+func (r *CommentRepository) GetThreadsByPostID(ctx context.Context, postID string, after *repository.Cursor, limit, maxDepth, maxReplies int) ([]repository.Comment, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var comments []repository.Comment
+
+	args := []interface{}{postID, maxDepth, maxReplies + 1}
+
+	rootsQuery := `SELECT * FROM comments WHERE post_id = $1 AND parent_id IS NULL`
+
+	if after != nil {
+		rootsQuery += ` AND (created_at, id) > ($4, $5)`
+
+		args = append(args, after.CreatedAt, after.ID)
+	}
+
+	rootsQuery += fmt.Sprintf(` ORDER BY created_at, id LIMIT $%d`, len(args)+1)
+
+	args = append(args, limit)
+
+	sqlQuery := `WITH RECURSIVE roots AS (` + rootsQuery + `),
+		thread AS (
+			SELECT roots.*, roots.id AS root_id, 0 AS depth FROM roots
+			UNION ALL
+			SELECT c.*, t.root_id, t.depth + 1 FROM comments c JOIN thread t ON c.parent_id = t.id WHERE t.depth < $2
+		),
+		ranked AS (
+			SELECT thread.*, ROW_NUMBER() OVER (PARTITION BY root_id ORDER BY depth = 0 DESC, created_at, id) - 1 AS reply_number 
+			FROM thread
+		)
+		SELECT ` + commentColumns + `, depth, 
+			depth = $2 AND EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = ranked.id) AS has_hidden_replies 
+		FROM ranked WHERE reply_number <= $3 ORDER BY created_at, id`
+
+	err := dbConn.SelectContext(ctx, &comments, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments threads by post id: %w", err)
+	}
+
+	return comments, nil
+}
+
+// GetRepliesByCommentID returns a page of the replies to the comment of the post down to maxDepth below it,
+// in the order they were written.
+func (r *CommentRepository) GetRepliesByCommentID(ctx context.Context, postID, commentID string, after *repository.Cursor, limit, maxDepth int) ([]repository.Comment, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var comments []repository.Comment
+
+	args := []interface{}{postID, commentID, maxDepth}
+
+	sqlQuery := `WITH RECURSIVE thread AS (
+			SELECT c.*, 1 AS depth FROM comments c WHERE c.post_id = $1 AND c.parent_id = $2
+			UNION ALL
+			SELECT c.*, t.depth + 1 FROM comments c JOIN thread t ON c.parent_id = t.id WHERE t.depth < $3
+		)
+		SELECT ` + commentColumns + `, depth, 
+			depth = $3 AND EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = thread.id) AS has_hidden_replies 
+		FROM thread`
+
+	if after != nil {
+		sqlQuery += ` WHERE (created_at, id) > ($4, $5)`
+
+		args = append(args, after.CreatedAt, after.ID)
+	}
+
+	sqlQuery += fmt.Sprintf(` ORDER BY created_at, id LIMIT $%d`, len(args)+1)
+
+	args = append(args, limit)
+
+	err := dbConn.SelectContext(ctx, &comments, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment replies by comment id: %w", err)
+	}
+
+	return comments, nil
+}
+
+func (r *CommentRepository) GetCountsByPostIDs(ctx context.Context, postIDs []string) (map[string]int, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var rows []struct {
+		PostID string `db:"post_id"`
+		Count  int    `db:"count"`
+	}
+
+	sqlQuery, args, err := sqlx.In(`SELECT post_id, COUNT(*) AS count FROM comments WHERE post_id IN (?) AND deleted_at IS NULL AND hidden_at IS NULL GROUP BY post_id`, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	sqlQuery = dbConn.Rebind(sqlQuery)
+
+	err = dbConn.SelectContext(ctx, &rows, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments counts: %w", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,219 @@
+package sqlx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"myfacebook/internal/db"
+	"myfacebook/internal/repository"
+)
+
+type PollRepository struct {
+	writeDB *db.DB
+	readDB  *db.DB
+}
+
+func NewPollRepository(writeDB, readDB *db.DB) *PollRepository {
+	return &PollRepository{
+		writeDB: writeDB,
+		readDB:  readDB,
+	}
+}
+
+func (r *PollRepository) Add(ctx context.Context, poll repository.Poll) error {
+	tx, err := r.writeDB.GetConnection().BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO polls (post_id, multiple_choice, closes_at) VALUES ($1, $2, $3)`,
+		poll.PostID, poll.MultipleChoice, poll.ClosesAt)
+	if err != nil {
+		return fmt.Errorf("failed to add poll to db: %w", err)
+	}
+
+	for _, option := range poll.Options {
+		_, err = tx.ExecContext(ctx, `INSERT INTO poll_options (post_id, option_id, text) VALUES ($1, $2, $3)`,
+			poll.PostID, option.ID, option.Text)
+		if err != nil {
+			return fmt.Errorf("failed to add poll option to db: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PollRepository) GetPollsByPostIDs(ctx context.Context, postIDs []string) (map[string]repository.Poll, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var polls []repository.Poll
+
+	sqlQuery, args, err := sqlx.In(`SELECT post_id, multiple_choice, closes_at FROM polls WHERE post_id IN (?)`, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	err = dbConn.SelectContext(ctx, &polls, dbConn.Rebind(sqlQuery), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get polls by post ids: %w", err)
+	}
+
+	pollsByPostID := make(map[string]repository.Poll, len(polls))
+
+	if len(polls) == 0 {
+		return pollsByPostID, nil
+	}
+
+	var options []struct {
+		PostID string `db:"post_id"`
+		repository.PollOption
+	}
+
+	sqlQuery, args, err = sqlx.In(`SELECT post_id, option_id, text FROM poll_options WHERE post_id IN (?) ORDER BY post_id, option_id`, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	err = dbConn.SelectContext(ctx, &options, dbConn.Rebind(sqlQuery), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get poll options by post ids: %w", err)
+	}
+
+	for _, poll := range polls {
+		pollsByPostID[poll.PostID] = poll
+	}
+
+	for _, option := range options {
+		poll := pollsByPostID[option.PostID]
+		poll.Options = append(poll.Options, option.PollOption)
+		pollsByPostID[option.PostID] = poll
+	}
+
+	return pollsByPostID, nil
+}
+
+// Vote stores the user choice, a second vote of the same user on the poll returns repository.ErrAlreadyExists.
+func (r *PollRepository) Vote(ctx context.Context, postID, userID string, optionIDs []int) error {
+	tx, err := r.writeDB.GetConnection().BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO poll_voters (post_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, postID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to add poll voter to db: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrAlreadyExists
+	}
+
+	for _, optionID := range optionIDs {
+		_, err = tx.ExecContext(ctx, `INSERT INTO poll_votes (post_id, user_id, option_id) VALUES ($1, $2, $3)`, postID, userID, optionID)
+		if err != nil {
+			return fmt.Errorf("failed to add poll vote to db: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PollRepository) GetCountsByPostIDs(ctx context.Context, postIDs []string) (map[string]repository.PollCounts, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var votersRows []struct {
+		PostID string `db:"post_id"`
+		Count  int    `db:"count"`
+	}
+
+	sqlQuery, args, err := sqlx.In(`SELECT post_id, COUNT(*) AS count FROM poll_voters WHERE post_id IN (?) GROUP BY post_id`, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	err = dbConn.SelectContext(ctx, &votersRows, dbConn.Rebind(sqlQuery), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get poll voters counts: %w", err)
+	}
+
+	var votesRows []struct {
+		PostID   string `db:"post_id"`
+		OptionID int    `db:"option_id"`
+		Count    int    `db:"count"`
+	}
+
+	sqlQuery, args, err = sqlx.In(`SELECT post_id, option_id, COUNT(*) AS count FROM poll_votes WHERE post_id IN (?) 
+		GROUP BY post_id, option_id`, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	err = dbConn.SelectContext(ctx, &votesRows, dbConn.Rebind(sqlQuery), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get poll votes counts: %w", err)
+	}
+
+	counts := make(map[string]repository.PollCounts, len(postIDs))
+
+	for _, postID := range postIDs {
+		counts[postID] = repository.PollCounts{Options: map[int]int{}}
+	}
+
+	for _, row := range votersRows {
+		postCounts := counts[row.PostID]
+		postCounts.Voters = row.Count
+		counts[row.PostID] = postCounts
+	}
+
+	for _, row := range votesRows {
+		counts[row.PostID].Options[row.OptionID] = row.Count
+	}
+
+	return counts, nil
+}
+
+func (r *PollRepository) GetUserVotesByPostIDs(ctx context.Context, userID string, postIDs []string) (map[string][]int, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var rows []struct {
+		PostID   string `db:"post_id"`
+		OptionID int    `db:"option_id"`
+	}
+
+	sqlQuery, args, err := sqlx.In(`SELECT post_id, option_id FROM poll_votes WHERE user_id = ? AND post_id IN (?) 
+		ORDER BY post_id, option_id`, userID, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	err = dbConn.SelectContext(ctx, &rows, dbConn.Rebind(sqlQuery), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user poll votes: %w", err)
+	}
+
+	votes := make(map[string][]int, len(rows))
+
+	for _, row := range rows {
+		votes[row.PostID] = append(votes[row.PostID], row.OptionID)
+	}
+
+	return votes, nil
+}
@@ -5,12 +5,16 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"myfacebook/internal/db"
 	"myfacebook/internal/repository"
 )
 
+const postColumns = `id, text, author_id, COALESCE(original_post_id::text, '') AS original_post_id, visibility, status, 
+	COALESCE(held_reason, '') AS held_reason, publish_at, created_at`
+
 type PostRepository struct {
 	writeDB *db.DB
 	readDB  *db.DB
@@ -26,8 +30,9 @@ func NewPostRepository(writeDB, readDB *db.DB) *PostRepository {
 func (r *PostRepository) Add(ctx context.Context, post repository.Post) error {
 	dbConn := r.writeDB.GetConnection()
 
-	sqlQuery := `INSERT INTO posts (id, text, author_id) 
-				VALUES (:id, :text, :author_id)`
+	sqlQuery := `INSERT INTO posts (id, text, author_id, original_post_id, visibility, status, held_reason, publish_at, created_at) 
+				VALUES (:id, :text, :author_id, NULLIF(:original_post_id, '')::uuid, :visibility, :status, NULLIF(:held_reason, ''), 
+				:publish_at, :created_at)`
 
 	_, err := dbConn.NamedExecContext(ctx, sqlQuery, post)
 	if err != nil {
@@ -37,15 +42,136 @@ func (r *PostRepository) Add(ctx context.Context, post repository.Post) error {
 	return nil
 }
 
-func (r *PostRepository) Delete(ctx context.Context, postID, authorID string) error {
+func (r *PostRepository) Delete(ctx context.Context, postID, authorID string) (*repository.Post, error) {
 	dbConn := r.writeDB.GetConnection()
 
-	_, err := dbConn.ExecContext(ctx, `DELETE FROM posts WHERE id=$1 AND author_id=$2`, postID, authorID)
+	var post repository.Post
+
+	sqlQuery := `UPDATE posts SET deleted_at=CURRENT_TIMESTAMP WHERE id=$1 AND author_id=$2 AND deleted_at IS NULL RETURNING ` + postColumns
+
+	err := dbConn.GetContext(ctx, &post, sqlQuery, postID, authorID)
 	if err != nil {
-		return fmt.Errorf("failed to delete post from db: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to delete post from db: %w", err)
 	}
 
-	return nil
+	return &post, nil
+}
+
+// RemoveByModeration deletes the post for good on behalf of a moderator, the author can neither see it in the trash
+// nor restore it. A post already in the trash is taken out of it.
+func (r *PostRepository) RemoveByModeration(ctx context.Context, postID string) (*repository.Post, error) {
+	dbConn := r.writeDB.GetConnection()
+
+	var post repository.Post
+
+	sqlQuery := `UPDATE posts SET deleted_at=COALESCE(deleted_at, CURRENT_TIMESTAMP), removed_by_moderation=TRUE 
+		WHERE id=$1 AND NOT removed_by_moderation RETURNING ` + postColumns
+
+	err := dbConn.GetContext(ctx, &post, sqlQuery, postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to remove post by moderation: %w", err)
+	}
+
+	return &post, nil
+}
+
+func (r *PostRepository) Restore(ctx context.Context, postID, authorID string) (*repository.Post, error) {
+	dbConn := r.writeDB.GetConnection()
+
+	var post repository.Post
+
+	// the posts removed by the moderators stay deleted
+	sqlQuery := `UPDATE posts SET deleted_at=NULL 
+		WHERE id=$1 AND author_id=$2 AND deleted_at IS NOT NULL AND NOT removed_by_moderation RETURNING ` + postColumns
+
+	err := dbConn.GetContext(ctx, &post, sqlQuery, postID, authorID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to restore post in db: %w", err)
+	}
+
+	return &post, nil
+}
+
+func (r *PostRepository) GetDeletedPostsByAuthorID(ctx context.Context, authorID string, before *repository.Cursor, limit int) ([]repository.Post, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var posts []repository.Post
+
+	args := []interface{}{authorID}
+
+	sqlQuery := `SELECT ` + postColumns + ` FROM posts WHERE author_id = $1 AND deleted_at IS NOT NULL AND NOT removed_by_moderation`
+
+	if before != nil {
+		sqlQuery += ` AND (created_at, id) < ($2, $3)`
+
+		args = append(args, before.CreatedAt, before.ID)
+	}
+
+	sqlQuery += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d`, len(args)+1)
+
+	args = append(args, limit)
+
+	err := dbConn.SelectContext(ctx, &posts, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted posts by author id: %w", err)
+	}
+
+	return posts, nil
+}
+
+// PurgeDeleted permanently removes up to limit posts deleted before the given time, together with their related rows,
+// it returns the ids of the purged posts.
+func (r *PostRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) ([]string, error) {
+	tx, err := r.writeDB.GetConnection().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	var postIDs []string
+
+	err = tx.SelectContext(ctx, &postIDs, `DELETE FROM posts WHERE id IN (
+			SELECT id FROM posts WHERE deleted_at < $1 LIMIT $2 FOR UPDATE SKIP LOCKED
+		) RETURNING id`, deletedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge deleted posts: %w", err)
+	}
+
+	if len(postIDs) == 0 {
+		return nil, nil
+	}
+
+	for _, table := range []string{"post_reactions", "comments", "post_tags", "post_mentions", "post_audience", "post_stats", "bookmarks",
+		"polls", "poll_options", "poll_votes", "poll_voters"} {
+		sqlQuery, args, err := sqlx.In(`DELETE FROM `+table+` WHERE post_id IN (?)`, postIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, tx.Rebind(sqlQuery), args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to purge %s of deleted posts: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return postIDs, nil
 }
 
 func (r *PostRepository) GetPostByID(ctx context.Context, postID string) (*repository.Post, error) {
@@ -53,7 +179,7 @@ func (r *PostRepository) GetPostByID(ctx context.Context, postID string) (*repos
 
 	var post repository.Post
 
-	sqlQuery := `SELECT id, text, author_id FROM posts WHERE id = $1`
+	sqlQuery := `SELECT ` + postColumns + ` FROM posts WHERE id = $1 AND deleted_at IS NULL`
 
 	err := dbConn.GetContext(ctx, &post, sqlQuery, postID)
 	if err != nil {
@@ -72,7 +198,7 @@ func (r *PostRepository) GetPostsByIDs(ctx context.Context, postIDs []string, of
 
 	var posts []repository.Post
 
-	sqlQuery, args, err := sqlx.In(`SELECT id, text, author_id FROM posts WHERE id IN (?) ORDER BY created_at DESC LIMIT ? OFFSET ?`,
+	sqlQuery, args, err := sqlx.In(`SELECT `+postColumns+` FROM posts WHERE id IN (?) AND deleted_at IS NULL ORDER BY created_at DESC LIMIT ? OFFSET ?`,
 		postIDs, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
@@ -88,12 +214,42 @@ func (r *PostRepository) GetPostsByIDs(ctx context.Context, postIDs []string, of
 	return posts, nil
 }
 
+// GetFriendsPosts returns the last published posts of the user friends not muted by the user, the newest first.
+// Posts created before since are left out unless since is zero.
+func (r *PostRepository) GetFriendsPosts(ctx context.Context, userID string, since time.Time, limit int) ([]repository.Post, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var posts []repository.Post
+
+	args := []interface{}{userID}
+
+	sqlQuery := `SELECT ` + postColumns + ` FROM posts 
+		WHERE author_id IN (SELECT friend_id FROM friends WHERE user_id = $1 AND muted_at IS NULL) AND status = 'published' AND deleted_at IS NULL`
+
+	if !since.IsZero() {
+		sqlQuery += ` AND created_at >= $2`
+
+		args = append(args, since.UTC())
+	}
+
+	sqlQuery += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d`, len(args)+1)
+
+	args = append(args, limit)
+
+	err := dbConn.SelectContext(ctx, &posts, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friends posts: %w", err)
+	}
+
+	return posts, nil
+}
+
 func (r *PostRepository) GetLastPostsIDsByAuthorIDs(ctx context.Context, authorIDs []string, createdAfterTimestampMilli int64, limit int) ([]string, error) {
 	dbConn := r.readDB.GetConnection()
 
 	var postsIDs []string
 
-	sqlQuery := `SELECT id FROM posts WHERE author_id IN (?)`
+	sqlQuery := `SELECT id FROM posts WHERE author_id IN (?) AND status = 'published' AND deleted_at IS NULL`
 
 	var args []interface{}
 	args = append(args, authorIDs)
@@ -126,9 +282,9 @@ func (r *PostRepository) GetLastPostsIDsByAuthorIDs(ctx context.Context, authorI
 func (r *PostRepository) Update(ctx context.Context, post repository.Post) error {
 	dbConn := r.writeDB.GetConnection()
 
-	sqlQuery := `UPDATE posts SET text=$2 WHERE id=$1`
+	sqlQuery := `UPDATE posts SET text=$2, visibility=$3, status=$4, held_reason=NULLIF($5, '') WHERE id=$1 AND deleted_at IS NULL`
 
-	res, err := dbConn.ExecContext(ctx, sqlQuery, post.ID, post.Text)
+	res, err := dbConn.ExecContext(ctx, sqlQuery, post.ID, post.Text, post.Visibility, post.Status, post.HeldReason)
 	if err != nil {
 		return fmt.Errorf("failed to update post in db: %w", err)
 	}
@@ -144,3 +300,554 @@ func (r *PostRepository) Update(ctx context.Context, post repository.Post) error
 
 	return nil
 }
+
+func (r *PostRepository) GetSharesCountsByPostIDs(ctx context.Context, postIDs []string) (map[string]int, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var rows []struct {
+		PostID string `db:"original_post_id"`
+		Count  int    `db:"count"`
+	}
+
+	sqlQuery, args, err := sqlx.In(`SELECT original_post_id, COUNT(*) AS count FROM posts WHERE original_post_id IN (?) AND deleted_at IS NULL GROUP BY original_post_id`, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	sqlQuery = dbConn.Rebind(sqlQuery)
+
+	err = dbConn.SelectContext(ctx, &rows, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shares counts: %w", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+
+	return counts, nil
+}
+
+func (r *PostRepository) SetTags(ctx context.Context, postID string, tags []string) error {
+	tx, err := r.writeDB.GetConnection().BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM post_tags WHERE post_id=$1`, postID)
+	if err != nil {
+		return fmt.Errorf("failed to delete post tags: %w", err)
+	}
+
+	for _, tag := range tags {
+		_, err = tx.ExecContext(ctx, `INSERT INTO post_tags (post_id, tag, post_created_at) 
+			SELECT id, $2, created_at FROM posts WHERE id=$1`, postID, tag)
+		if err != nil {
+			return fmt.Errorf("failed to add post tag: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SetMentions replaces mentioned users of the post and returns ids of the users that were not mentioned before.
+func (r *PostRepository) SetMentions(ctx context.Context, postID string, userIDs []string) ([]string, error) {
+	tx, err := r.writeDB.GetConnection().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	if len(userIDs) == 0 {
+		_, err = tx.ExecContext(ctx, `DELETE FROM post_mentions WHERE post_id=$1`, postID)
+	} else {
+		var sqlQuery string
+
+		var args []interface{}
+
+		sqlQuery, args, err = sqlx.In(`DELETE FROM post_mentions WHERE post_id=? AND user_id NOT IN (?)`, postID, userIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, tx.Rebind(sqlQuery), args...)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete post mentions: %w", err)
+	}
+
+	var newUserIDs []string
+
+	for _, userID := range userIDs {
+		var insertedUserID string
+
+		err = tx.GetContext(ctx, &insertedUserID, `INSERT INTO post_mentions (post_id, user_id) VALUES ($1, $2) 
+			ON CONFLICT DO NOTHING RETURNING user_id`, postID, userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+
+			return nil, fmt.Errorf("failed to add post mention: %w", err)
+		}
+
+		newUserIDs = append(newUserIDs, insertedUserID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return newUserIDs, nil
+}
+
+func (r *PostRepository) GetPostsByTag(ctx context.Context, tag string, before *repository.Cursor, limit int) ([]repository.Post, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var posts []repository.Post
+
+	args := []interface{}{tag}
+
+	sqlQuery := `SELECT p.id, p.text, p.author_id, COALESCE(p.original_post_id::text, '') AS original_post_id, p.visibility, 
+		p.status, p.publish_at, p.created_at 
+		FROM post_tags t JOIN posts p ON p.id = t.post_id WHERE t.tag = $1 AND p.status = 'published' AND p.deleted_at IS NULL`
+
+	if before != nil {
+		sqlQuery += ` AND (t.post_created_at, t.post_id) < ($2, $3)`
+
+		args = append(args, before.CreatedAt, before.ID)
+	}
+
+	sqlQuery += fmt.Sprintf(` ORDER BY t.post_created_at DESC, t.post_id DESC LIMIT $%d`, len(args)+1)
+
+	args = append(args, limit)
+
+	err := dbConn.SelectContext(ctx, &posts, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get posts by tag: %w", err)
+	}
+
+	return posts, nil
+}
+
+func (r *PostRepository) GetPostsByAuthorID(ctx context.Context, authorID string, before *repository.Cursor, limit int) ([]repository.Post, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var posts []repository.Post
+
+	args := []interface{}{authorID}
+
+	sqlQuery := `SELECT ` + postColumns + ` FROM posts WHERE author_id = $1 AND status = 'published' AND deleted_at IS NULL`
+
+	if before != nil {
+		sqlQuery += ` AND (created_at, id) < ($2, $3)`
+
+		args = append(args, before.CreatedAt, before.ID)
+	}
+
+	sqlQuery += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d`, len(args)+1)
+
+	args = append(args, limit)
+
+	err := dbConn.SelectContext(ctx, &posts, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get posts by author id: %w", err)
+	}
+
+	return posts, nil
+}
+
+// SearchPosts finds posts the viewer may see matching the query in russian or english, the best matching first.
+// Posts of banned authors and of the friends muted by the viewer are left out.
+// Snippet is the post text fragment with the matched words wrapped in <b> tags, it is built with the configuration
+// the text matches the query in, as a headline highlights only the words stemmed the way of its configuration.
+func (r *PostRepository) SearchPosts(ctx context.Context, viewerID, query string, offset, limit int) ([]repository.PostSearchResult, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var results []repository.PostSearchResult
+
+	sqlQuery := `SELECT p.id, p.text, p.author_id, COALESCE(p.original_post_id::text, '') AS original_post_id, p.visibility, 
+			p.status, p.publish_at, p.created_at, 
+			ts_rank_cd(p.search_vector, q.query) AS rank, 
+			CASE WHEN to_tsvector('russian', COALESCE(p.text, '')) @@ q.russian_query 
+				THEN ts_headline('russian', COALESCE(p.text, ''), q.russian_query, 'StartSel=<b>, StopSel=</b>, MaxFragments=2') 
+				ELSE ts_headline('english', COALESCE(p.text, ''), q.english_query, 'StartSel=<b>, StopSel=</b>, MaxFragments=2') 
+			END AS snippet 
+		FROM posts p, 
+			(SELECT websearch_to_tsquery('russian', $1) AS russian_query, websearch_to_tsquery('english', $1) AS english_query, 
+				websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1) AS query) q 
+		WHERE p.search_vector @@ q.query AND p.status = 'published' AND p.deleted_at IS NULL 
+			AND (p.author_id = $2 
+				OR p.visibility = 'public' 
+				OR p.visibility = 'friends' AND EXISTS (SELECT 1 FROM friends f WHERE f.user_id = p.author_id AND f.friend_id = $2) 
+				OR p.visibility = 'list' AND EXISTS (SELECT 1 FROM post_audience pa WHERE pa.post_id = p.id AND pa.user_id = $2)) 
+			AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = p.author_id AND u.banned_at IS NOT NULL) 
+			AND NOT EXISTS (SELECT 1 FROM friends m WHERE m.user_id = $2 AND m.friend_id = p.author_id AND m.muted_at IS NOT NULL) 
+		ORDER BY rank DESC, p.created_at DESC, p.id 
+		LIMIT $3 OFFSET $4`
+
+	err := dbConn.SelectContext(ctx, &results, sqlQuery, query, viewerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search posts: %w", err)
+	}
+
+	return results, nil
+}
+
+func (r *PostRepository) SetAudience(ctx context.Context, postID string, userIDs []string) error {
+	tx, err := r.writeDB.GetConnection().BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM post_audience WHERE post_id=$1`, postID)
+	if err != nil {
+		return fmt.Errorf("failed to delete post audience: %w", err)
+	}
+
+	for _, userID := range userIDs {
+		_, err = tx.ExecContext(ctx, `INSERT INTO post_audience (post_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, postID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to add post audience user: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetAudienceAmong returns the given users that are in the post custom audience.
+func (r *PostRepository) GetAudienceAmong(ctx context.Context, postID string, userIDs []string) ([]string, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	dbConn := r.readDB.GetConnection()
+
+	var ids []string
+
+	sqlQuery, args, err := sqlx.In(`SELECT user_id FROM post_audience WHERE post_id = ? AND user_id IN (?)`, postID, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	sqlQuery = dbConn.Rebind(sqlQuery)
+
+	err = dbConn.SelectContext(ctx, &ids, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post audience: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetAudiencePostsIDs returns ids of the posts which custom audience includes the user.
+func (r *PostRepository) GetAudiencePostsIDs(ctx context.Context, userID string, postIDs []string) ([]string, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var ids []string
+
+	sqlQuery, args, err := sqlx.In(`SELECT post_id FROM post_audience WHERE user_id = ? AND post_id IN (?)`, userID, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	sqlQuery = dbConn.Rebind(sqlQuery)
+
+	err = dbConn.SelectContext(ctx, &ids, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audience posts ids: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (r *PostRepository) GetScheduledPostsByAuthorID(ctx context.Context, authorID string) ([]repository.Post, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var posts []repository.Post
+
+	sqlQuery := `SELECT ` + postColumns + ` FROM posts WHERE author_id = $1 AND status = 'scheduled' AND deleted_at IS NULL 
+		ORDER BY publish_at, id`
+
+	err := dbConn.SelectContext(ctx, &posts, sqlQuery, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled posts by author id: %w", err)
+	}
+
+	return posts, nil
+}
+
+func (r *PostRepository) Reschedule(ctx context.Context, postID, authorID string, publishAt time.Time) error {
+	dbConn := r.writeDB.GetConnection()
+
+	sqlQuery := `UPDATE posts SET publish_at=$3 WHERE id=$1 AND author_id=$2 AND status = 'scheduled' AND deleted_at IS NULL`
+
+	res, err := dbConn.ExecContext(ctx, sqlQuery, postID, authorID, publishAt)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule post in db: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// CancelScheduled permanently removes the scheduled post, it has never been seen by anyone but the author.
+func (r *PostRepository) CancelScheduled(ctx context.Context, postID, authorID string) error {
+	tx, err := r.writeDB.GetConnection().BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM posts WHERE id=$1 AND author_id=$2 AND status = 'scheduled'`, postID, authorID)
+	if err != nil {
+		return fmt.Errorf("failed to delete scheduled post from db: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	for _, table := range []string{"post_tags", "post_mentions", "post_audience", "polls", "poll_options"} {
+		_, err = tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE post_id=$1`, postID)
+		if err != nil {
+			return fmt.Errorf("failed to delete %s of scheduled post: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// PublishDuePosts publishes up to limit scheduled posts whose publish time has come and marks them to be fanned out,
+// it returns the number of published posts. Rows are locked with SKIP LOCKED, so several schedulers never publish
+// the same post.
+func (r *PostRepository) PublishDuePosts(ctx context.Context, limit int) (int, error) {
+	tx, err := r.writeDB.GetConnection().BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	var postIDs []string
+
+	err = tx.SelectContext(ctx, &postIDs, `SELECT id FROM posts 
+		WHERE status = 'scheduled' AND publish_at <= CURRENT_TIMESTAMP AND deleted_at IS NULL 
+		ORDER BY publish_at LIMIT $1 FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due scheduled posts: %w", err)
+	}
+
+	if len(postIDs) == 0 {
+		return 0, nil
+	}
+
+	// the post appears in feeds and tag listings as created at the time it is published
+	sqlQuery, args, err := sqlx.In(`UPDATE posts SET status = 'published', created_at = publish_at, fanout_pending = TRUE 
+		WHERE id IN (?)`, postIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, tx.Rebind(sqlQuery), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to publish scheduled posts: %w", err)
+	}
+
+	sqlQuery, args, err = sqlx.In(`UPDATE post_tags t SET post_created_at = p.created_at FROM posts p 
+		WHERE p.id = t.post_id AND t.post_id IN (?)`, postIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, tx.Rebind(sqlQuery), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update tags of scheduled posts: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(postIDs), nil
+}
+
+// GetFanoutPendingPosts returns up to limit posts published by PublishDuePosts and not fanned out yet, the earliest first.
+// The write db is read, so a post just marked fanned out is not returned again.
+func (r *PostRepository) GetFanoutPendingPosts(ctx context.Context, limit int) ([]repository.Post, error) {
+	dbConn := r.writeDB.GetConnection()
+
+	var posts []repository.Post
+
+	sqlQuery := `SELECT ` + postColumns + ` FROM posts WHERE fanout_pending AND status = 'published' AND deleted_at IS NULL 
+		ORDER BY created_at, id LIMIT $1`
+
+	err := dbConn.SelectContext(ctx, &posts, sqlQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fan-out pending posts: %w", err)
+	}
+
+	return posts, nil
+}
+
+func (r *PostRepository) MarkFannedOut(ctx context.Context, postIDs []string) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+
+	dbConn := r.writeDB.GetConnection()
+
+	sqlQuery, args, err := sqlx.In(`UPDATE posts SET fanout_pending = FALSE WHERE id IN (?)`, postIDs)
+	if err != nil {
+		return fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	_, err = dbConn.ExecContext(ctx, dbConn.Rebind(sqlQuery), args...)
+	if err != nil {
+		return fmt.Errorf("failed to mark posts fanned out: %w", err)
+	}
+
+	return nil
+}
+
+// GetHeldPosts returns the posts waiting for review, the oldest first.
+func (r *PostRepository) GetHeldPosts(ctx context.Context, offset, limit int) ([]repository.Post, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var posts []repository.Post
+
+	sqlQuery := `SELECT ` + postColumns + ` FROM posts WHERE status = 'held' AND deleted_at IS NULL 
+		ORDER BY created_at, id LIMIT $1 OFFSET $2`
+
+	err := dbConn.SelectContext(ctx, &posts, sqlQuery, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get held posts: %w", err)
+	}
+
+	return posts, nil
+}
+
+// ApproveHeld publishes the held post, or schedules it when its publish time has not come yet.
+func (r *PostRepository) ApproveHeld(ctx context.Context, postID string) (*repository.Post, error) {
+	return r.approveHeld(ctx, postID, "")
+}
+
+// ReleaseReportHold approves the post only when it is held because of the reports,
+// the posts held by the moderation pipeline are left to the moderators.
+func (r *PostRepository) ReleaseReportHold(ctx context.Context, postID string) (*repository.Post, error) {
+	return r.approveHeld(ctx, postID, repository.PostHeldReasonReports)
+}
+
+func (r *PostRepository) approveHeld(ctx context.Context, postID, heldReason string) (*repository.Post, error) {
+	tx, err := r.writeDB.GetConnection().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	var post repository.Post
+
+	// a post published on approval appears in feeds and tag listings as created at the approval time, like a scheduled one
+	// does at its publish time. A post held because of the reports has been in the feeds already and keeps its place.
+	sqlQuery := `UPDATE posts SET status = CASE WHEN publish_at > CURRENT_TIMESTAMP THEN 'scheduled' ELSE 'published' END, 
+			created_at = CASE WHEN publish_at > CURRENT_TIMESTAMP OR held_reason = 'reports' THEN created_at 
+				ELSE GREATEST(created_at, CURRENT_TIMESTAMP) END, 
+			held_reason = NULL
+		WHERE id=$1 AND status = 'held' AND deleted_at IS NULL AND ($2 = '' OR held_reason = $2) RETURNING ` + postColumns
+
+	err = tx.GetContext(ctx, &post, sqlQuery, postID, heldReason)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to approve held post: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE post_tags SET post_created_at = $2 WHERE post_id = $1`, post.ID, post.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update tags of approved post: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &post, nil
+}
+
+// Hold takes the published post off for review of its reports.
+func (r *PostRepository) Hold(ctx context.Context, postID string) (*repository.Post, error) {
+	dbConn := r.writeDB.GetConnection()
+
+	var post repository.Post
+
+	sqlQuery := `UPDATE posts SET status = 'held', held_reason = 'reports' 
+		WHERE id=$1 AND status = 'published' AND deleted_at IS NULL RETURNING ` + postColumns
+
+	err := dbConn.GetContext(ctx, &post, sqlQuery, postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to hold post: %w", err)
+	}
+
+	return &post, nil
+}
+
+func (r *PostRepository) RejectHeld(ctx context.Context, postID string) error {
+	dbConn := r.writeDB.GetConnection()
+
+	res, err := dbConn.ExecContext(ctx, `UPDATE posts SET status = 'rejected' WHERE id=$1 AND status = 'held' AND deleted_at IS NULL`, postID)
+	if err != nil {
+		return fmt.Errorf("failed to reject held post: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"myfacebook/internal/db"
+	"myfacebook/internal/repository"
+)
+
+const postDraftColumns = `id, author_id, text, visibility, audience, updated_at, expires_at`
+
+type postDraftRow struct {
+	ID         string         `db:"id"`
+	AuthorID   string         `db:"author_id"`
+	Text       string         `db:"text"`
+	Visibility string         `db:"visibility"`
+	Audience   pq.StringArray `db:"audience"`
+	UpdatedAt  time.Time      `db:"updated_at"`
+	ExpiresAt  time.Time      `db:"expires_at"`
+}
+
+func (row postDraftRow) toPostDraft() repository.PostDraft {
+	return repository.PostDraft{
+		ID:         row.ID,
+		AuthorID:   row.AuthorID,
+		Text:       row.Text,
+		Visibility: row.Visibility,
+		Audience:   row.Audience,
+		UpdatedAt:  row.UpdatedAt,
+		ExpiresAt:  row.ExpiresAt,
+	}
+}
+
+type PostDraftRepository struct {
+	writeDB *db.DB
+	readDB  *db.DB
+}
+
+func NewPostDraftRepository(writeDB, readDB *db.DB) *PostDraftRepository {
+	return &PostDraftRepository{
+		writeDB: writeDB,
+		readDB:  readDB,
+	}
+}
+
+func (r *PostDraftRepository) Add(ctx context.Context, draft repository.PostDraft, ttl time.Duration) error {
+	dbConn := r.writeDB.GetConnection()
+
+	sqlQuery := `INSERT INTO post_drafts (id, author_id, text, visibility, audience, expires_at) 
+				VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP + make_interval(secs => $6))`
+
+	_, err := dbConn.ExecContext(ctx, sqlQuery, draft.ID, draft.AuthorID, draft.Text, draft.Visibility,
+		pq.StringArray(draft.Audience), ttl.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to add post draft to db: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostDraftRepository) Update(ctx context.Context, draft repository.PostDraft, ttl time.Duration) error {
+	dbConn := r.writeDB.GetConnection()
+
+	sqlQuery := `UPDATE post_drafts SET text=$3, visibility=$4, audience=$5, updated_at=CURRENT_TIMESTAMP, 
+				expires_at=CURRENT_TIMESTAMP + make_interval(secs => $6) 
+				WHERE id=$1 AND author_id=$2 AND expires_at > CURRENT_TIMESTAMP`
+
+	res, err := dbConn.ExecContext(ctx, sqlQuery, draft.ID, draft.AuthorID, draft.Text, draft.Visibility,
+		pq.StringArray(draft.Audience), ttl.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to update post draft in db: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PostDraftRepository) GetDraftsByAuthorID(ctx context.Context, authorID string) ([]repository.PostDraft, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var rows []postDraftRow
+
+	sqlQuery := `SELECT ` + postDraftColumns + ` FROM post_drafts WHERE author_id=$1 AND expires_at > CURRENT_TIMESTAMP 
+				ORDER BY updated_at DESC, id`
+
+	err := dbConn.SelectContext(ctx, &rows, sqlQuery, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post drafts by author id: %w", err)
+	}
+
+	drafts := make([]repository.PostDraft, 0, len(rows))
+
+	for _, row := range rows {
+		drafts = append(drafts, row.toPostDraft())
+	}
+
+	return drafts, nil
+}
+
+// Claim deletes the draft and returns it, so only one of concurrent publishes of the draft gets it.
+func (r *PostDraftRepository) Claim(ctx context.Context, draftID, authorID string) (*repository.PostDraft, error) {
+	dbConn := r.writeDB.GetConnection()
+
+	var row postDraftRow
+
+	sqlQuery := `DELETE FROM post_drafts WHERE id=$1 AND author_id=$2 AND expires_at > CURRENT_TIMESTAMP 
+				RETURNING ` + postDraftColumns
+
+	err := dbConn.GetContext(ctx, &row, sqlQuery, draftID, authorID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to claim post draft: %w", err)
+	}
+
+	draft := row.toPostDraft()
+
+	return &draft, nil
+}
+
+func (r *PostDraftRepository) DeleteExpired(ctx context.Context) (int, error) {
+	dbConn := r.writeDB.GetConnection()
+
+	res, err := dbConn.ExecContext(ctx, `DELETE FROM post_drafts WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired post drafts from db: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
@@ -0,0 +1,119 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"myfacebook/internal/db"
+	"myfacebook/internal/repository"
+)
+
+type PostReactionRepository struct {
+	writeDB *db.DB
+	readDB  *db.DB
+}
+
+func NewPostReactionRepository(writeDB, readDB *db.DB) *PostReactionRepository {
+	return &PostReactionRepository{
+		writeDB: writeDB,
+		readDB:  readDB,
+	}
+}
+
+func (r *PostReactionRepository) Set(ctx context.Context, reaction repository.PostReaction) (string, error) {
+	dbConn := r.writeDB.GetConnection()
+
+	sqlQuery := `WITH prev AS (SELECT type FROM post_reactions WHERE post_id=$1 AND user_id=$2)
+		INSERT INTO post_reactions (post_id, user_id, type) VALUES ($1, $2, $3)
+		ON CONFLICT (post_id, user_id) DO UPDATE SET type=EXCLUDED.type, updated_at=CURRENT_TIMESTAMP
+		RETURNING (SELECT type FROM prev)`
+
+	var prevType sql.NullString
+
+	err := dbConn.GetContext(ctx, &prevType, sqlQuery, reaction.PostID, reaction.UserID, reaction.Type)
+	if err != nil {
+		return "", fmt.Errorf("failed to set post reaction: %w", err)
+	}
+
+	return prevType.String, nil
+}
+
+func (r *PostReactionRepository) Delete(ctx context.Context, postID, userID string) (string, error) {
+	dbConn := r.writeDB.GetConnection()
+
+	var reactionType string
+
+	err := dbConn.GetContext(ctx, &reactionType, `DELETE FROM post_reactions WHERE post_id=$1 AND user_id=$2 RETURNING type`, postID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", repository.ErrNotFound
+		}
+
+		return "", fmt.Errorf("failed to delete post reaction: %w", err)
+	}
+
+	return reactionType, nil
+}
+
+func (r *PostReactionRepository) GetCountsByPostIDs(ctx context.Context, postIDs []string) (map[string]map[string]int, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var rows []struct {
+		PostID string `db:"post_id"`
+		Type   string `db:"type"`
+		Count  int    `db:"count"`
+	}
+
+	sqlQuery, args, err := sqlx.In(`SELECT post_id, type, COUNT(*) AS count FROM post_reactions WHERE post_id IN (?) GROUP BY post_id, type`, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	sqlQuery = dbConn.Rebind(sqlQuery)
+
+	err = dbConn.SelectContext(ctx, &rows, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post reactions counts: %w", err)
+	}
+
+	counts := make(map[string]map[string]int, len(postIDs))
+
+	for _, postID := range postIDs {
+		counts[postID] = make(map[string]int)
+	}
+
+	for _, row := range rows {
+		counts[row.PostID][row.Type] = row.Count
+	}
+
+	return counts, nil
+}
+
+func (r *PostReactionRepository) GetUserReactionsByPostIDs(ctx context.Context, userID string, postIDs []string) (map[string]string, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var reactions []repository.PostReaction
+
+	sqlQuery, args, err := sqlx.In(`SELECT post_id, user_id, type FROM post_reactions WHERE user_id = ? AND post_id IN (?)`, userID, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	sqlQuery = dbConn.Rebind(sqlQuery)
+
+	err = dbConn.SelectContext(ctx, &reactions, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user post reactions: %w", err)
+	}
+
+	userReactions := make(map[string]string, len(reactions))
+
+	for _, reaction := range reactions {
+		userReactions[reaction.PostID] = reaction.Type
+	}
+
+	return userReactions, nil
+}
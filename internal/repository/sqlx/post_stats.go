@@ -0,0 +1,65 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"myfacebook/internal/db"
+	"myfacebook/internal/repository"
+)
+
+type PostStatsRepository struct {
+	writeDB *db.DB
+	readDB  *db.DB
+}
+
+func NewPostStatsRepository(writeDB, readDB *db.DB) *PostStatsRepository {
+	return &PostStatsRepository{
+		writeDB: writeDB,
+		readDB:  readDB,
+	}
+}
+
+func (r *PostStatsRepository) SetUniqueViewers(ctx context.Context, uniqueViewers map[string]int64) error {
+	tx, err := r.writeDB.GetConnection().BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	for postID, count := range uniqueViewers {
+		// the counter never goes down, a smaller value comes from a lost redis key
+		_, err = tx.ExecContext(ctx, `INSERT INTO post_stats (post_id, unique_viewers) VALUES ($1, $2) 
+			ON CONFLICT (post_id) DO UPDATE SET unique_viewers = GREATEST(post_stats.unique_viewers, EXCLUDED.unique_viewers), 
+			updated_at = CURRENT_TIMESTAMP`, postID, count)
+		if err != nil {
+			return fmt.Errorf("failed to set post unique viewers: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostStatsRepository) GetPostStatsByPostID(ctx context.Context, postID string) (*repository.PostStats, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var postStats repository.PostStats
+
+	err := dbConn.GetContext(ctx, &postStats, `SELECT post_id, unique_viewers FROM post_stats WHERE post_id = $1`, postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get post stats by post id: %w", err)
+	}
+
+	return &postStats, nil
+}
@@ -0,0 +1,138 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"myfacebook/internal/db"
+	"myfacebook/internal/repository"
+)
+
+const reportColumns = `id, target_type, target_id, target_author_id, reporter_id, reason, comment, status,
+	COALESCE(assignee_id::text, '') AS assignee_id, action, created_at, resolved_at`
+
+type ReportRepository struct {
+	writeDB *db.DB
+	readDB  *db.DB
+}
+
+func NewReportRepository(writeDB, readDB *db.DB) *ReportRepository {
+	return &ReportRepository{
+		writeDB: writeDB,
+		readDB:  readDB,
+	}
+}
+
+// Add saves the report, a repeated report of the same target by the same reporter gives ErrAlreadyExists.
+func (r *ReportRepository) Add(ctx context.Context, report repository.Report) error {
+	dbConn := r.writeDB.GetConnection()
+
+	sqlQuery := `INSERT INTO reports (id, target_type, target_id, target_author_id, reporter_id, reason, comment)
+				VALUES (:id, :target_type, :target_id, :target_author_id, :reporter_id, :reason, :comment)
+				ON CONFLICT (reporter_id, target_type, target_id) DO NOTHING`
+
+	res, err := dbConn.NamedExecContext(ctx, sqlQuery, report)
+	if err != nil {
+		return fmt.Errorf("failed to add report to db: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrAlreadyExists
+	}
+
+	return nil
+}
+
+func (r *ReportRepository) GetReportByID(ctx context.Context, reportID string) (*repository.Report, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var report repository.Report
+
+	err := dbConn.GetContext(ctx, &report, `SELECT `+reportColumns+` FROM reports WHERE id = $1`, reportID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get report by id: %w", err)
+	}
+
+	return &report, nil
+}
+
+// GetReportsByStatus returns a page of reports with the given status, the oldest first.
+func (r *ReportRepository) GetReportsByStatus(ctx context.Context, status string, offset, limit int) ([]repository.Report, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var reports []repository.Report
+
+	sqlQuery := `SELECT ` + reportColumns + ` FROM reports WHERE status = $1 ORDER BY created_at, id OFFSET $2 LIMIT $3`
+
+	err := dbConn.SelectContext(ctx, &reports, sqlQuery, status, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reports by status: %w", err)
+	}
+
+	return reports, nil
+}
+
+// GetPendingCountByTarget returns the number of not yet resolved reports of the target.
+func (r *ReportRepository) GetPendingCountByTarget(ctx context.Context, targetType, targetID string) (int, error) {
+	dbConn := r.writeDB.GetConnection()
+
+	var count int
+
+	sqlQuery := `SELECT COUNT(*) FROM reports WHERE target_type = $1 AND target_id = $2 AND status <> 'resolved'`
+
+	err := dbConn.GetContext(ctx, &count, sqlQuery, targetType, targetID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending reports count: %w", err)
+	}
+
+	return count, nil
+}
+
+// Claim assigns the open report to the moderator, ErrNotFound is returned when the report is not open.
+func (r *ReportRepository) Claim(ctx context.Context, reportID, assigneeID string) error {
+	dbConn := r.writeDB.GetConnection()
+
+	sqlQuery := `UPDATE reports SET status = 'claimed', assignee_id = $2 WHERE id = $1 AND status = 'open'`
+
+	res, err := dbConn.ExecContext(ctx, sqlQuery, reportID, assigneeID)
+	if err != nil {
+		return fmt.Errorf("failed to claim report: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// ResolveByTarget resolves all pending reports of the target with the taken action.
+func (r *ReportRepository) ResolveByTarget(ctx context.Context, targetType, targetID, assigneeID, action string) error {
+	dbConn := r.writeDB.GetConnection()
+
+	sqlQuery := `UPDATE reports SET status = 'resolved', assignee_id = $3, action = $4, resolved_at = CURRENT_TIMESTAMP
+		WHERE target_type = $1 AND target_id = $2 AND status <> 'resolved'`
+
+	_, err := dbConn.ExecContext(ctx, sqlQuery, targetType, targetID, assigneeID, action)
+	if err != nil {
+		return fmt.Errorf("failed to resolve reports: %w", err)
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/jmoiron/sqlx"
 	"myfacebook/internal/db"
 	"myfacebook/internal/repository"
 )
@@ -41,7 +42,7 @@ func (r *UserRepository) GetUserByID(ctx context.Context, userID string) (*repos
 
 	var user repository.User
 
-	sqlQuery := `SELECT id, first_name, last_name, TO_CHAR(birthdate, 'YYYY-MM-DD') as birthdate, city, biography, password, token FROM users WHERE id = $1`
+	sqlQuery := `SELECT id, first_name, last_name, TO_CHAR(birthdate, 'YYYY-MM-DD') as birthdate, city, biography, password, token, role, banned_at IS NOT NULL AS banned FROM users WHERE id = $1`
 
 	err := dbConn.GetContext(ctx, &user, sqlQuery, userID)
 	if err != nil {
@@ -55,12 +56,33 @@ func (r *UserRepository) GetUserByID(ctx context.Context, userID string) (*repos
 	return &user, nil
 }
 
+func (r *UserRepository) GetUsersByIDs(ctx context.Context, userIDs []string) ([]repository.User, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var users []repository.User
+
+	sqlQuery, args, err := sqlx.In(`SELECT id, first_name, last_name, TO_CHAR(birthdate, 'YYYY-MM-DD') as birthdate, city, biography, password, token, role, banned_at IS NOT NULL AS banned 
+		FROM users WHERE id IN (?)`, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	sqlQuery = dbConn.Rebind(sqlQuery)
+
+	err = dbConn.SelectContext(ctx, &users, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users by ids: %w", err)
+	}
+
+	return users, nil
+}
+
 func (r *UserRepository) GetUsersByFirstnameAndLastname(ctx context.Context, firstName, lastName string) ([]repository.User, error) {
 	dbConn := r.readDB.GetConnection()
 
 	var users []repository.User
 
-	sqlQuery := `SELECT id, first_name, last_name, TO_CHAR(birthdate, 'YYYY-MM-DD') as birthdate, city, biography, password, token 
+	sqlQuery := `SELECT id, first_name, last_name, TO_CHAR(birthdate, 'YYYY-MM-DD') as birthdate, city, biography, password, token, role, banned_at IS NOT NULL AS banned 
 		FROM users WHERE first_name LIKE $1 AND last_name LIKE $2 ORDER BY id`
 
 	err := dbConn.SelectContext(ctx, &users, sqlQuery, firstName+"%", lastName+"%")
@@ -88,12 +110,53 @@ func (r *UserRepository) UpdateUserToken(ctx context.Context, userID, token stri
 	return nil
 }
 
+// GetUsersIDsAfter returns a page of users ids ordered by id, starting after the given one or from the first when it is empty.
+func (r *UserRepository) GetUsersIDsAfter(ctx context.Context, afterUserID string, limit int) ([]string, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var ids []string
+
+	var err error
+
+	if afterUserID == "" {
+		err = dbConn.SelectContext(ctx, &ids, `SELECT id FROM users ORDER BY id LIMIT $1`, limit)
+	} else {
+		err = dbConn.SelectContext(ctx, &ids, `SELECT id FROM users WHERE id > $1 ORDER BY id LIMIT $2`, afterUserID, limit)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to select users ids: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (r *UserRepository) Ban(ctx context.Context, userID string) error {
+	dbConn := r.writeDB.GetConnection()
+
+	res, err := dbConn.ExecContext(ctx, `UPDATE users SET banned_at=CURRENT_TIMESTAMP WHERE id=$1 AND banned_at IS NULL`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to ban user: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
 func (r *UserRepository) GetUserByToken(ctx context.Context, token string) (*repository.User, error) {
 	dbConn := r.readDB.GetConnection()
 
 	var user repository.User
 
-	sqlQuery := `SELECT id, first_name, last_name, TO_CHAR(birthdate, 'YYYY-MM-DD') as birthdate, city, biography, password, token FROM users WHERE token = $1`
+	sqlQuery := `SELECT id, first_name, last_name, TO_CHAR(birthdate, 'YYYY-MM-DD') as birthdate, city, biography, password, token, role, banned_at IS NOT NULL AS banned FROM users WHERE token = $1`
 
 	err := dbConn.GetContext(ctx, &user, sqlQuery, token)
 	if err != nil {
@@ -120,28 +183,87 @@ func (r *UserRepository) AddFriend(ctx context.Context, userID, friendID string)
 	return nil
 }
 
-func (r *UserRepository) DeleteFriend(ctx context.Context, userID, friendID string) error {
+// DeleteFriend returns the number of deleted friends rows, the same friend could be added more than once.
+func (r *UserRepository) DeleteFriend(ctx context.Context, userID, friendID string) (int, error) {
+	dbConn := r.writeDB.GetConnection()
+
+	sqlQuery := `DELETE FROM friends WHERE user_id=$1 AND friend_id=$2`
+
+	res, err := dbConn.ExecContext(ctx, sqlQuery, userID, friendID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete friend: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows by delete statement: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// MuteFriend hides the friend posts from the user feed, the friend stays in the user friends.
+func (r *UserRepository) MuteFriend(ctx context.Context, userID, friendID string) error {
+	return r.setFriendMutedAt(ctx, userID, friendID, `COALESCE(muted_at, NOW())`)
+}
+
+func (r *UserRepository) UnmuteFriend(ctx context.Context, userID, friendID string) error {
+	return r.setFriendMutedAt(ctx, userID, friendID, `NULL`)
+}
+
+func (r *UserRepository) setFriendMutedAt(ctx context.Context, userID, friendID, mutedAt string) error {
 	dbConn := r.writeDB.GetConnection()
 
-	sqlQuery := `DELETE FROM friends WHERE user_id=$1 AND friend_id=$2 RETURNING *`
+	sqlQuery := `UPDATE friends SET muted_at = ` + mutedAt + ` WHERE user_id=$1 AND friend_id=$2`
 
 	res, err := dbConn.ExecContext(ctx, sqlQuery, userID, friendID)
 	if err != nil {
-		return fmt.Errorf("failed to delete friend: %w", err)
+		return fmt.Errorf("failed to update friend muted at: %w", err)
 	}
 
 	rowsAffected, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get affected rows by delete statement: %w", err)
+		return fmt.Errorf("failed to get affected rows by update statement: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return nil
+		return repository.ErrNotFound
 	}
 
 	return nil
 }
 
+func (r *UserRepository) GetMutedFriendsIDs(ctx context.Context, userID string) ([]string, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var ids []string
+
+	sqlQuery := `SELECT DISTINCT friend_id FROM friends WHERE user_id=$1 AND muted_at IS NOT NULL`
+
+	err := dbConn.SelectContext(ctx, &ids, sqlQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select muted friends ids: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetUsersIDsMutingFriendID returns the users who muted the friend.
+func (r *UserRepository) GetUsersIDsMutingFriendID(ctx context.Context, friendID string) ([]string, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var ids []string
+
+	sqlQuery := `SELECT DISTINCT user_id FROM friends WHERE friend_id=$1 AND muted_at IS NOT NULL`
+
+	err := dbConn.SelectContext(ctx, &ids, sqlQuery, friendID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select users ids muting friend: %w", err)
+	}
+
+	return ids, nil
+}
+
 func (r *UserRepository) GetUsersIDsByFriendID(ctx context.Context, userID string) ([]string, error) {
 	dbConn := r.readDB.GetConnection()
 
@@ -157,32 +279,115 @@ func (r *UserRepository) GetUsersIDsByFriendID(ctx context.Context, userID strin
 	return ids, nil
 }
 
-func (r *UserRepository) GetPopularFriendsIDsByUserID(ctx context.Context, userID string, popularFriendUsersCount int) ([]string, error) {
+// GetUsersIDsByFriendIDAfter returns a page of the users having the friend ordered by id, starting after the given one
+// or from the first when it is empty.
+func (r *UserRepository) GetUsersIDsByFriendIDAfter(ctx context.Context, friendID, afterUserID string, limit int) ([]string, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var ids []string
+
+	var err error
+
+	if afterUserID == "" {
+		err = dbConn.SelectContext(ctx, &ids, `SELECT DISTINCT user_id FROM friends WHERE friend_id=$1 
+			ORDER BY user_id LIMIT $2`, friendID, limit)
+	} else {
+		err = dbConn.SelectContext(ctx, &ids, `SELECT DISTINCT user_id FROM friends WHERE friend_id=$1 AND user_id > $2 
+			ORDER BY user_id LIMIT $3`, friendID, afterUserID, limit)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to select users ids page: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetFriendsIDsAmong returns the given users that are friends of the user.
+func (r *UserRepository) GetFriendsIDsAmong(ctx context.Context, userID string, friendIDs []string) ([]string, error) {
+	if len(friendIDs) == 0 {
+		return nil, nil
+	}
+
+	dbConn := r.readDB.GetConnection()
+
+	var ids []string
+
+	sqlQuery, args, err := sqlx.In(`SELECT friend_id FROM friends WHERE user_id = ? AND friend_id IN (?)`, userID, friendIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
+
+	sqlQuery = dbConn.Rebind(sqlQuery)
+
+	err = dbConn.SelectContext(ctx, &ids, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select friends ids: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (r *UserRepository) HasFriend(ctx context.Context, userID, friendID string) (bool, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var exists bool
+
+	sqlQuery := `SELECT EXISTS(SELECT 1 FROM friends WHERE user_id=$1 AND friend_id=$2)`
+
+	err := dbConn.GetContext(ctx, &exists, sqlQuery, userID, friendID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check friend: %w", err)
+	}
+
+	return exists, nil
+}
+
+// GetCelebrityFriendsIDsByUserID returns the user friends whose posts are not pushed to the feeds by the fan-out.
+func (r *UserRepository) GetCelebrityFriendsIDsByUserID(ctx context.Context, userID string) ([]string, error) {
 	dbConn := r.readDB.GetConnection()
 
 	var ids []string
 
-	sqlQuery := `SELECT f.friend_id FROM friends f WHERE f.user_id=$1 GROUP BY f.friend_id HAVING COUNT(DISTINCT f.user_id) >= $2`
+	sqlQuery := `SELECT DISTINCT f.friend_id FROM friends f JOIN user_stats s ON s.user_id = f.friend_id 
+		WHERE f.user_id=$1 AND s.celebrity`
 
-	err := dbConn.SelectContext(ctx, &ids, sqlQuery, userID, popularFriendUsersCount)
+	err := dbConn.SelectContext(ctx, &ids, sqlQuery, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to select popular friends ids: %w", err)
+		return nil, fmt.Errorf("failed to select celebrity friends ids: %w", err)
 	}
 
 	return ids, nil
 }
 
-func (r *UserRepository) GetUsersCountByFriendID(ctx context.Context, friendID string) (int, error) {
+// GetMutualFriendsCounts returns by each of the other users the number of friends they have in common with the user.
+func (r *UserRepository) GetMutualFriendsCounts(ctx context.Context, userID string, othersIDs []string) (map[string]int, error) {
 	dbConn := r.readDB.GetConnection()
 
-	sqlQuery := `SELECT COUNT(id) FROM friends WHERE friend_id=$1`
+	var rows []struct {
+		UserID string `db:"user_id"`
+		Count  int    `db:"count"`
+	}
+
+	sqlQuery, args, err := sqlx.In(`SELECT other.user_id, COUNT(*) AS count FROM friends own 
+		JOIN friends other ON other.friend_id = own.friend_id 
+		WHERE own.user_id = ? AND other.user_id IN (?) GROUP BY other.user_id`, userID, othersIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sql IN query: %w", err)
+	}
 
-	var count int
+	sqlQuery = dbConn.Rebind(sqlQuery)
 
-	err := dbConn.GetContext(ctx, &count, sqlQuery, friendID)
+	err = dbConn.SelectContext(ctx, &rows, sqlQuery, args...)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get users count by friend id from db: %w", err)
+		return nil, fmt.Errorf("failed to get mutual friends counts: %w", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+
+	for _, row := range rows {
+		counts[row.UserID] = row.Count
 	}
 
-	return count, nil
+	return counts, nil
 }
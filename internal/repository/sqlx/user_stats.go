@@ -0,0 +1,88 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"myfacebook/internal/db"
+	"myfacebook/internal/repository"
+)
+
+type UserStatsRepository struct {
+	writeDB *db.DB
+	readDB  *db.DB
+}
+
+func NewUserStatsRepository(writeDB, readDB *db.DB) *UserStatsRepository {
+	return &UserStatsRepository{
+		writeDB: writeDB,
+		readDB:  readDB,
+	}
+}
+
+// AddFollowers changes the user followers count by delta and updates the celebrity flag, the count never goes below zero.
+func (r *UserStatsRepository) AddFollowers(ctx context.Context, userID string, delta int,
+	thresholds repository.CelebrityThresholds,
+) (*repository.UserStats, error) {
+	dbConn := r.writeDB.GetConnection()
+
+	var userStats repository.UserStats
+
+	sqlQuery := `INSERT INTO user_stats (user_id, followers_count, celebrity) VALUES ($1, GREATEST($2, 0), GREATEST($2, 0) >= $3) 
+		ON CONFLICT (user_id) DO UPDATE SET followers_count = GREATEST(user_stats.followers_count + $2, 0), 
+		celebrity = CASE WHEN user_stats.celebrity THEN GREATEST(user_stats.followers_count + $2, 0) >= $4 
+			ELSE GREATEST(user_stats.followers_count + $2, 0) >= $3 END, 
+		updated_at = CURRENT_TIMESTAMP 
+		RETURNING user_id, followers_count, celebrity`
+
+	err := dbConn.GetContext(ctx, &userStats, sqlQuery, userID, delta, thresholds.Enter, thresholds.Leave)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add user followers: %w", err)
+	}
+
+	return &userStats, nil
+}
+
+func (r *UserStatsRepository) GetUserStatsByUserID(ctx context.Context, userID string) (*repository.UserStats, error) {
+	dbConn := r.readDB.GetConnection()
+
+	var userStats repository.UserStats
+
+	err := dbConn.GetContext(ctx, &userStats, `SELECT user_id, followers_count, celebrity FROM user_stats WHERE user_id = $1`, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get user stats by user id: %w", err)
+	}
+
+	return &userStats, nil
+}
+
+// Recompute counts the followers of all the users from scratch, the celebrity flags keep their hysteresis.
+// It returns the number of users whose stats were recomputed.
+func (r *UserStatsRepository) Recompute(ctx context.Context, thresholds repository.CelebrityThresholds) (int, error) {
+	dbConn := r.writeDB.GetConnection()
+
+	sqlQuery := `INSERT INTO user_stats (user_id, followers_count, celebrity) 
+		SELECT u.id, COALESCE(f.count, 0), COALESCE(f.count, 0) >= $1 FROM users u 
+		LEFT JOIN (SELECT friend_id, COUNT(*) AS count FROM friends GROUP BY friend_id) f ON f.friend_id = u.id 
+		ON CONFLICT (user_id) DO UPDATE SET followers_count = EXCLUDED.followers_count, 
+		celebrity = CASE WHEN user_stats.celebrity THEN EXCLUDED.followers_count >= $2 ELSE EXCLUDED.followers_count >= $1 END, 
+		updated_at = CURRENT_TIMESTAMP`
+
+	res, err := dbConn.ExecContext(ctx, sqlQuery, thresholds.Enter, thresholds.Leave)
+	if err != nil {
+		return 0, fmt.Errorf("failed to recompute user stats: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows by recompute statement: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
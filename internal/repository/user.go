@@ -2,6 +2,11 @@ package repository
 
 import "context"
 
+const (
+	UserRoleUser  = "user"
+	UserRoleAdmin = "admin"
+)
+
 type User struct {
 	ID        string `db:"id"`
 	FirstName string `db:"first_name"`
@@ -11,17 +16,29 @@ type User struct {
 	City      string `db:"city"`
 	Password  string `db:"password"`
 	Token     string `db:"token"`
+	Role      string `db:"role"`
+	Banned    bool   `db:"banned"`
 }
 
 type UserRepository interface {
 	Add(ctx context.Context, user User) error
 	GetUserByID(ctx context.Context, userID string) (*User, error)
+	GetUsersByIDs(ctx context.Context, userIDs []string) ([]User, error)
+	GetUsersIDsAfter(ctx context.Context, afterUserID string, limit int) ([]string, error)
 	GetUsersByFirstnameAndLastname(ctx context.Context, firstName, lastName string) ([]User, error)
 	UpdateUserToken(ctx context.Context, userID, token string) error
 	GetUserByToken(ctx context.Context, token string) (*User, error)
+	Ban(ctx context.Context, userID string) error
 	AddFriend(ctx context.Context, userID, friendID string) error
-	DeleteFriend(ctx context.Context, userID, friendID string) error
+	DeleteFriend(ctx context.Context, userID, friendID string) (int, error)
+	MuteFriend(ctx context.Context, userID, friendID string) error
+	UnmuteFriend(ctx context.Context, userID, friendID string) error
+	GetMutedFriendsIDs(ctx context.Context, userID string) ([]string, error)
+	GetUsersIDsMutingFriendID(ctx context.Context, friendID string) ([]string, error)
 	GetUsersIDsByFriendID(ctx context.Context, friendID string) ([]string, error)
-	GetPopularFriendsIDsByUserID(ctx context.Context, userID string, popularFriendUsersCount int) ([]string, error)
-	GetUsersCountByFriendID(ctx context.Context, friendID string) (int, error)
+	GetUsersIDsByFriendIDAfter(ctx context.Context, friendID, afterUserID string, limit int) ([]string, error)
+	GetFriendsIDsAmong(ctx context.Context, userID string, friendIDs []string) ([]string, error)
+	HasFriend(ctx context.Context, userID, friendID string) (bool, error)
+	GetCelebrityFriendsIDsByUserID(ctx context.Context, userID string) ([]string, error)
+	GetMutualFriendsCounts(ctx context.Context, userID string, othersIDs []string) (map[string]int, error)
 }
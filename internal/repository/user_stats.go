@@ -0,0 +1,22 @@
+package repository
+
+import "context"
+
+type UserStats struct {
+	UserID         string `db:"user_id"`
+	FollowersCount int    `db:"followers_count"`
+	Celebrity      bool   `db:"celebrity"`
+}
+
+// CelebrityThresholds make the celebrity flag sticky: a user becomes a celebrity at Enter followers
+// and stops being one only below Leave followers.
+type CelebrityThresholds struct {
+	Enter int
+	Leave int
+}
+
+type UserStatsRepository interface {
+	AddFollowers(ctx context.Context, userID string, delta int, thresholds CelebrityThresholds) (*UserStats, error)
+	GetUserStatsByUserID(ctx context.Context, userID string) (*UserStats, error)
+	Recompute(ctx context.Context, thresholds CelebrityThresholds) (int, error)
+}
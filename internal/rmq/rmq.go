@@ -179,6 +179,26 @@ func (rmq *RMQ) Publish(ctx context.Context, exchangeName, routingKey string, me
 	return nil
 }
 
+// PublishMany publishes the message once for each routing key on the same channel. The messages are written
+// one after another without waiting for the broker, so a large batch costs no round trip per key.
+func (rmq *RMQ) PublishMany(ctx context.Context, exchangeName string, routingKeys []string, message []byte) error {
+	channel := rmq.getChannel()
+
+	publishing := amqp.Publishing{
+		ContentType: "text/json",
+		Body:        message,
+	}
+
+	for _, routingKey := range routingKeys {
+		err := channel.PublishWithContext(ctx, exchangeName, routingKey, false, false, publishing)
+		if err != nil {
+			return fmt.Errorf("rmq failed to publish message to exchange %q: %w", exchangeName, err)
+		}
+	}
+
+	return nil
+}
+
 func (rmq *RMQ) BindQueueToExchange(queueName, exchangeName, routingKey string) error {
 	err := rmq.getChannel().QueueBind(
 		queueName,
@@ -194,6 +214,16 @@ func (rmq *RMQ) BindQueueToExchange(queueName, exchangeName, routingKey string)
 	return nil
 }
 
+// ConsumeWithPrefetch consumes the queue having at most prefetchCount messages not acked at a time.
+func (rmq *RMQ) ConsumeWithPrefetch(ctx context.Context, queueName string, prefetchCount int) (<-chan amqp.Delivery, error) {
+	err := rmq.getChannel().Qos(prefetchCount, 0, false)
+	if err != nil {
+		return nil, fmt.Errorf("rmq failed to set prefetch count for queue %q: %w", queueName, err)
+	}
+
+	return rmq.Consume(ctx, queueName)
+}
+
 func (rmq *RMQ) Consume(_ context.Context, queueName string) (<-chan amqp.Delivery, error) {
 	msgs, err := rmq.getChannel().Consume(queueName, "", false, false, false, false, nil)
 	if err != nil {
@@ -0,0 +1,91 @@
+package userstats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"myfacebook/internal/config"
+	"myfacebook/internal/repository"
+	"myfacebook/internal/userstatscache"
+)
+
+// Counter keeps the users followers counts and celebrity flags in the db and in the cache.
+// The posts of celebrities are pulled into the feeds on read instead of being pushed by the fan-out.
+type Counter struct {
+	userStatsRepository repository.UserStatsRepository
+	userStatsCache      *userstatscache.Cache
+	thresholds          repository.CelebrityThresholds
+}
+
+func New(userStatsRepository repository.UserStatsRepository, userStatsCache *userstatscache.Cache,
+	envConfig *config.EnvConfig,
+) *Counter {
+	return &Counter{
+		userStatsRepository: userStatsRepository,
+		userStatsCache:      userStatsCache,
+		thresholds: repository.CelebrityThresholds{
+			Enter: envConfig.PopularFriendUsersCount,
+			Leave: envConfig.PopularFriendUsersCount * (100 - envConfig.PopularFriendUsersHysteresisPercent) / 100,
+		},
+	}
+}
+
+// AddFollowers changes the user followers count by delta, a negative one for unfollows.
+func (c *Counter) AddFollowers(ctx context.Context, userID string, delta int) error {
+	userStats, err := c.userStatsRepository.AddFollowers(ctx, userID, delta, c.thresholds)
+	if err != nil {
+		return fmt.Errorf("userstats failed to add followers: %w", err)
+	}
+
+	err = c.userStatsCache.Set(ctx, *userStats)
+	if err != nil {
+		return fmt.Errorf("userstats failed to cache user stats: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Counter) IsCelebrity(ctx context.Context, userID string) (bool, error) {
+	userStats, err := c.userStatsCache.Get(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("userstats failed to get cached user stats: %w", err)
+	}
+
+	if userStats != nil {
+		return userStats.Celebrity, nil
+	}
+
+	userStats, err = c.userStatsRepository.GetUserStatsByUserID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			return false, fmt.Errorf("userstats failed to get user stats: %w", err)
+		}
+
+		// nobody has ever followed the user
+		userStats = &repository.UserStats{UserID: userID}
+	}
+
+	err = c.userStatsCache.Set(ctx, *userStats)
+	if err != nil {
+		return false, fmt.Errorf("userstats failed to cache user stats: %w", err)
+	}
+
+	return userStats.Celebrity, nil
+}
+
+// Recompute counts the followers of all the users in the db from scratch and drops the cached stats.
+// It returns the number of users whose stats were recomputed.
+func (c *Counter) Recompute(ctx context.Context) (int, error) {
+	usersCount, err := c.userStatsRepository.Recompute(ctx, c.thresholds)
+	if err != nil {
+		return 0, fmt.Errorf("userstats failed to recompute user stats: %w", err)
+	}
+
+	_, err = c.userStatsCache.DeleteAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("userstats failed to delete cached user stats: %w", err)
+	}
+
+	return usersCount, nil
+}
@@ -0,0 +1,111 @@
+package userstatscache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"myfacebook/internal/rdb"
+	"myfacebook/internal/repository"
+)
+
+const (
+	userStatsCachePrefix = "userstats:user_"
+	cacheTTL             = 7 * 24 * time.Hour
+	deleteScanCount      = 100
+)
+
+// Cache keeps the users followers counts and celebrity flags, so the fan-out does not count followers in the db
+// for every post.
+type Cache struct {
+	redisDB *rdb.RedisDB
+}
+
+func New(redisDB *rdb.RedisDB) *Cache {
+	return &Cache{
+		redisDB: redisDB,
+	}
+}
+
+func (c *Cache) Set(ctx context.Context, userStats repository.UserStats) error {
+	key := userStatsCachePrefix + userStats.UserID
+
+	_, err := c.redisDB.GetClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"followers_count", userStats.FollowersCount,
+			"celebrity", userStats.Celebrity,
+		)
+		pipe.Expire(ctx, key, cacheTTL)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("userstatscache failed to set user %q stats: %w", userStats.UserID, err)
+	}
+
+	return nil
+}
+
+// Get returns nil when the user stats are not cached.
+func (c *Cache) Get(ctx context.Context, userID string) (*repository.UserStats, error) {
+	values, err := c.redisDB.GetClient().HMGet(ctx, userStatsCachePrefix+userID, "followers_count", "celebrity").Result()
+	if err != nil {
+		return nil, fmt.Errorf("userstatscache failed to get user %q stats: %w", userID, err)
+	}
+
+	followersCountValue, ok := values[0].(string)
+	if !ok {
+		return nil, nil
+	}
+
+	celebrityValue, ok := values[1].(string)
+	if !ok {
+		return nil, nil
+	}
+
+	followersCount, err := strconv.Atoi(followersCountValue)
+	if err != nil {
+		return nil, fmt.Errorf("userstatscache failed to parse user %q followers count: %w", userID, err)
+	}
+
+	// go-redis writes booleans as 1 and 0
+	return &repository.UserStats{
+		UserID:         userID,
+		FollowersCount: followersCount,
+		Celebrity:      celebrityValue == "1",
+	}, nil
+}
+
+// DeleteAll drops the cached stats of all the users, they are cached again from the db on use.
+// It returns the number of deleted keys.
+func (c *Cache) DeleteAll(ctx context.Context) (int, error) {
+	client := c.redisDB.GetClient()
+
+	var (
+		cursor  uint64
+		deleted int
+	)
+
+	for {
+		keys, nextCursor, err := client.Scan(ctx, cursor, userStatsCachePrefix+"*", deleteScanCount).Result()
+		if err != nil {
+			return deleted, fmt.Errorf("userstatscache failed to scan user stats keys: %w", err)
+		}
+
+		if len(keys) > 0 {
+			count, err := client.Del(ctx, keys...).Result()
+			if err != nil {
+				return deleted, fmt.Errorf("userstatscache failed to delete user stats keys: %w", err)
+			}
+
+			deleted += int(count)
+		}
+
+		cursor = nextCursor
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
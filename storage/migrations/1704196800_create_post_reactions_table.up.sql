@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TABLE post_reactions
+(
+    post_id    UUID        NOT NULL,
+    user_id    UUID        NOT NULL,
+    type       VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, user_id)
+);
+
+CREATE INDEX post_reactions_user_id_idx ON post_reactions (user_id);
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+CREATE TABLE comments
+(
+    id         UUID PRIMARY KEY,
+    post_id    UUID NOT NULL,
+    parent_id  UUID,
+    author_id  UUID NOT NULL,
+    text       TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX comments_post_id_created_at_id_idx ON comments (post_id, created_at, id) WHERE parent_id IS NULL;
+CREATE INDEX comments_parent_id_idx ON comments (parent_id);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE posts
+    ADD original_post_id UUID;
+
+CREATE INDEX posts_original_post_id_idx ON posts (original_post_id);
+
+COMMIT;
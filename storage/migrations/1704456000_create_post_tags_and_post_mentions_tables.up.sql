@@ -0,0 +1,22 @@
+BEGIN;
+
+CREATE TABLE post_tags
+(
+    post_id         UUID         NOT NULL,
+    tag             VARCHAR(255) NOT NULL,
+    post_created_at TIMESTAMP    NOT NULL,
+    PRIMARY KEY (post_id, tag)
+);
+
+CREATE INDEX post_tags_tag_post_created_at_post_id_idx ON post_tags (tag, post_created_at DESC, post_id DESC);
+
+CREATE TABLE post_mentions
+(
+    post_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    PRIMARY KEY (post_id, user_id)
+);
+
+CREATE INDEX post_mentions_user_id_idx ON post_mentions (user_id);
+
+COMMIT;
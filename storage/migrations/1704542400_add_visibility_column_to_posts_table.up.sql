@@ -0,0 +1,18 @@
+BEGIN;
+
+ALTER TABLE posts
+    ADD visibility VARCHAR(16) NOT NULL DEFAULT 'public';
+
+CREATE TABLE post_audience
+(
+    post_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    PRIMARY KEY (post_id, user_id)
+);
+
+CREATE INDEX post_audience_user_id_idx ON post_audience (user_id);
+
+CREATE INDEX friends_user_id_friend_id_idx ON friends (user_id, friend_id);
+CREATE INDEX friends_friend_id_idx ON friends (friend_id);
+
+COMMIT;
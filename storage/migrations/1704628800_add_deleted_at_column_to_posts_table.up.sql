@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE posts
+    ADD deleted_at TIMESTAMP;
+
+CREATE INDEX posts_deleted_author_id_created_at_id_idx ON posts (author_id, created_at DESC, id DESC) WHERE deleted_at IS NOT NULL;
+CREATE INDEX posts_deleted_at_idx ON posts (deleted_at) WHERE deleted_at IS NOT NULL;
+
+COMMIT;
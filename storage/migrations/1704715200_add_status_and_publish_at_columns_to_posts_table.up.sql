@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE posts
+    ADD status     VARCHAR(16) NOT NULL DEFAULT 'published',
+    ADD publish_at TIMESTAMP;
+
+CREATE INDEX posts_scheduled_publish_at_idx ON posts (publish_at) WHERE status = 'scheduled';
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE post_drafts
+(
+    id         UUID PRIMARY KEY,
+    author_id  UUID        NOT NULL,
+    text       TEXT        NOT NULL DEFAULT '',
+    visibility VARCHAR(16) NOT NULL DEFAULT '',
+    audience   UUID[]      NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP   NOT NULL
+);
+
+CREATE INDEX post_drafts_author_id_updated_at_idx ON post_drafts (author_id, updated_at DESC);
+CREATE INDEX post_drafts_expires_at_idx ON post_drafts (expires_at);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+CREATE INDEX posts_author_id_created_at_id_idx ON posts (author_id, created_at DESC, id DESC) WHERE deleted_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+-- users write both in russian and english, so the text is indexed with both configurations
+ALTER TABLE posts
+    ADD search_vector TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('russian', COALESCE(text, '')) || to_tsvector('english', COALESCE(text, ''))
+    ) STORED;
+
+CREATE INDEX posts_search_vector_idx ON posts USING GIN (search_vector);
+
+COMMIT;
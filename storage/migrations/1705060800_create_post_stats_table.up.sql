@@ -0,0 +1,10 @@
+BEGIN;
+
+CREATE TABLE post_stats
+(
+    post_id        UUID PRIMARY KEY,
+    unique_viewers BIGINT    NOT NULL DEFAULT 0,
+    updated_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+CREATE TABLE bookmarks
+(
+    user_id    UUID      NOT NULL,
+    post_id    UUID      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE INDEX bookmarks_user_id_created_at_post_id_idx ON bookmarks (user_id, created_at DESC, post_id DESC);
+CREATE INDEX bookmarks_post_id_idx ON bookmarks (post_id);
+
+COMMIT;
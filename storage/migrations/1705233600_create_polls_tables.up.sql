@@ -0,0 +1,39 @@
+BEGIN;
+
+CREATE TABLE polls
+(
+    post_id         UUID PRIMARY KEY,
+    multiple_choice BOOLEAN   NOT NULL DEFAULT FALSE,
+    closes_at       TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE poll_options
+(
+    post_id   UUID     NOT NULL,
+    option_id SMALLINT NOT NULL,
+    text      TEXT     NOT NULL,
+    PRIMARY KEY (post_id, option_id)
+);
+
+-- one row per user makes a second vote fail, whatever the number of chosen options
+CREATE TABLE poll_voters
+(
+    post_id    UUID      NOT NULL,
+    user_id    UUID      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, user_id)
+);
+
+CREATE TABLE poll_votes
+(
+    post_id   UUID     NOT NULL,
+    user_id   UUID     NOT NULL,
+    option_id SMALLINT NOT NULL,
+    PRIMARY KEY (post_id, user_id, option_id),
+    FOREIGN KEY (post_id, user_id) REFERENCES poll_voters (post_id, user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX poll_votes_post_id_option_id_idx ON poll_votes (post_id, option_id);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE users
+    ADD role VARCHAR(16) NOT NULL DEFAULT 'user';
+
+CREATE INDEX posts_held_created_at_idx ON posts (created_at, id) WHERE status = 'held';
+
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN;
+
+CREATE TABLE reports
+(
+    id               UUID PRIMARY KEY,
+    target_type      VARCHAR(16) NOT NULL,
+    target_id        VARCHAR(64) NOT NULL,
+    target_author_id UUID        NOT NULL,
+    reporter_id      UUID        NOT NULL,
+    reason           VARCHAR(32) NOT NULL,
+    comment          TEXT        NOT NULL DEFAULT '',
+    status           VARCHAR(16) NOT NULL DEFAULT 'open',
+    assignee_id      UUID        NULL,
+    action           VARCHAR(16) NOT NULL DEFAULT '',
+    created_at       TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at      TIMESTAMP   NULL,
+    UNIQUE (reporter_id, target_type, target_id)
+);
+
+CREATE INDEX reports_status_created_at_id_idx ON reports (status, created_at, id);
+CREATE INDEX reports_target_type_target_id_idx ON reports (target_type, target_id) WHERE status <> 'resolved';
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE users ADD COLUMN banned_at TIMESTAMP NULL;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE comments ADD COLUMN hidden_at TIMESTAMP NULL;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE friends ADD COLUMN muted_at TIMESTAMP NULL;
+
+CREATE INDEX friends_friend_id_muted_idx ON friends (friend_id) WHERE muted_at IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+CREATE TABLE user_stats
+(
+    user_id         UUID      NOT NULL PRIMARY KEY,
+    followers_count INT       NOT NULL DEFAULT 0,
+    celebrity       BOOLEAN   NOT NULL DEFAULT FALSE,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- the celebrity flag is seeded with the default POPULAR_FRIEND_USERS_COUNT, so existing celebrities are not fanned out
+-- after the upgrade; other thresholds are applied by the admin recompute
+INSERT INTO user_stats (user_id, followers_count, celebrity)
+SELECT friend_id, COUNT(*), COUNT(*) >= 100 FROM friends GROUP BY friend_id;
+
+CREATE INDEX user_stats_celebrity_idx ON user_stats (user_id) WHERE celebrity;
+
+COMMIT;
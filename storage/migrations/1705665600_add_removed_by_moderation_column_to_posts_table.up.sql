@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE posts
+    ADD removed_by_moderation BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE posts
+    ADD held_reason VARCHAR(16);
+
+-- the reason of the posts held before is unknown, they are left to the moderators
+UPDATE posts SET held_reason = 'moderation' WHERE status = 'held';
+
+COMMIT;
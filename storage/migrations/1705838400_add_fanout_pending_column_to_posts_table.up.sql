@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE posts
+    ADD fanout_pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX posts_fanout_pending_created_at_idx ON posts (created_at, id) WHERE fanout_pending;
+
+COMMIT;